@@ -3,154 +3,475 @@ package app
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	consulConfig "github.com/liuxiong332/kratos-starter/config/consul"
 
-	"github.com/liuxiong332/kratos-starter/registry/consul"
 	consulRegistry "github.com/liuxiong332/kratos-starter/registry/consul"
+	memoryRegistry "github.com/liuxiong332/kratos-starter/registry/memory"
 
+	"github.com/google/uuid"
 	"github.com/hashicorp/consul/api"
 
-	vaultConfig "github.com/liuxiong332/kratos-starter/config/vault"
-
-	vaultApi "github.com/hashicorp/vault/api"
-
 	appLog "github.com/liuxiong332/kratos-starter/logger"
 
+	"github.com/liuxiong332/kratos-starter/health"
+	"github.com/liuxiong332/kratos-starter/logger/audit"
 	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+	"github.com/liuxiong332/kratos-starter/metrics"
+	"github.com/liuxiong332/kratos-starter/secret"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/transport"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/go-kratos/kratos/v2/config"
 	"github.com/go-kratos/kratos/v2/config/env"
-	"github.com/go-kratos/kratos/v2/config/file"
 )
 
 func GetInstance(discovery registry.Discovery, serviceName string, logHelper *log.Helper) string {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
-	watcher, err := discovery.Watch(ctx, serviceName)
+	instance, err := lookupInstance(ctx, discovery, serviceName)
 	if err != nil {
 		logHelper.Fatal(err)
 	}
+	return instance
+}
+
+// lookupInstance returns the first endpoint of the service, or empty string if
+// the service is not discovered before ctx is done.
+func lookupInstance(ctx context.Context, discovery registry.Discovery, serviceName string) (string, error) {
+	watcher, err := discovery.Watch(ctx, serviceName)
+	if err != nil {
+		return "", err
+	}
 	svcInstants, err := watcher.Next()
 	if err != nil {
-		logHelper.Error(err)
+		log.Errorf("Failed to discover service %s, err: %v", serviceName, err)
 	}
 	if err := watcher.Stop(); err != nil {
-		logHelper.Errorf("Failed to http client watch stop, err: %v", err)
+		log.Errorf("Failed to http client watch stop, err: %v", err)
 	}
 
 	for _, svc := range svcInstants {
 		for _, e := range svc.Endpoints {
-			return e
+			return e, nil
 		}
 	}
-	return ""
+	return "", nil
 }
 
 type AppStarter struct {
-	Logger   *zapLog.Logger
-	Registry *consul.Registry
-	Config   config.Config
+	// ID is the app instance id
+	ID       string
+	Name     string
+	Version  string
+	Metadata map[string]string
+
+	Logger *zapLog.Logger
+	// Registry is the consul registry, the in-memory registry in local mode or
+	// the registry of WithRegistry
+	Registry Registry
+	// ConsulRegistry is the consul registry created by the starter, nil in
+	// local mode or with WithRegistry
+	ConsulRegistry *consulRegistry.Registry
+	Config         config.Config
+	// Health has the checkers of the starter components, the checkers of the
+	// app components can be registered
+	Health *health.Registry
+	// Metrics is nil if the metrics is not enabled by WithMetrics
+	Metrics *metrics.Metrics
+	// TracerProvider is nil if no tracing config, see WithTracing
+	TracerProvider *sdktrace.TracerProvider
+	// Audit is nil if no audit config, it is enabled for the starter
+	// components by audit.Enable
+	Audit *audit.Logger
+
+	// sources is the config sources in merge order
+	sources []*trackedSource
+
+	servers     []transport.Server
+	serversLock sync.Mutex
+
+	// secretMasker is cloned from the default masker with the secret patterns
+	// and the loaded secrets of the starter
+	secretMasker *secret.Masker
+
+	observers map[string][]func()
+	// pendingKeys are the observed keys not found yet
+	pendingKeys   map[string]struct{}
+	observersLock sync.Mutex
+
+	// rootLogger is the Logger without the trace valuers, it is closed by Close
+	rootLogger *zapLog.Logger
+	// ownLogger and ownRegistry are false if the logger and the registry are
+	// passed by WithLogger and WithRegistry, they are not closed then
+	ownLogger   bool
+	ownRegistry bool
+	// cancelWatch stops the watchers of the config sources
+	cancelWatch context.CancelFunc
+
+	closeOnce sync.Once
+	closeErr  error
+
+	startupReport StartupReport
 }
 
-func newVaultConfig(registry *consul.Registry, logHelper *log.Helper, appName string, bootstrapConfig *BootstrapConfig) config.Source {
-	// 初始化 vault config
-	vaultAddr := GetInstance(registry, "vault", logHelper)
+// masker returns the masker of the secret config.
+func (s *AppStarter) masker() *secret.Masker {
+	if s.secretMasker == nil {
+		return secret.Default()
+	}
+	return s.secretMasker
+}
 
-	if vaultAddr != "" {
-		if !strings.HasPrefix(vaultAddr, "http://") {
-			vaultAddr = "http://" + vaultAddr
-		}
+func (s *AppStarter) addServer(srv transport.Server) {
+	s.serversLock.Lock()
+	defer s.serversLock.Unlock()
+	s.servers = append(s.servers, srv)
+}
 
-		vaultClient, err := vaultApi.NewClient(&vaultApi.Config{
-			Address: vaultAddr,
-		})
-		if err != nil {
-			logHelper.Fatal(err)
-		}
-		vaultClient.SetToken(bootstrapConfig.VaultToken)
+// Servers returns the servers created by the starter.
+func (s *AppStarter) Servers() []transport.Server {
+	s.serversLock.Lock()
+	defer s.serversLock.Unlock()
+	return append([]transport.Server{}, s.servers...)
+}
 
-		vaultSrc, err := vaultConfig.New(vaultClient, vaultConfig.WithPath(fmt.Sprintf("secret/%s", appName)))
-		if err != nil {
-			logHelper.Fatalf("New vault config error: %v", err)
-		}
-		return vaultSrc
+// consulAddress returns the configured consul address or the default one.
+func consulAddress(bootstrapConfig *BootstrapConfig) string {
+	if bootstrapConfig.ConsulAddress != "" {
+		return bootstrapConfig.ConsulAddress
 	}
-	return nil
+	return api.DefaultConfig().Address
 }
 
-func NewApp(appName string, bootstrapConfig *BootstrapConfig) *AppStarter {
+func newConsulClient(bootstrapConfig *BootstrapConfig) (*api.Client, error) {
+	consulCfg := &api.Config{
+		Address:    bootstrapConfig.ConsulAddress,
+		Token:      bootstrapConfig.ConsulToken,
+		Datacenter: bootstrapConfig.ConsulDatacenter,
+		Namespace:  bootstrapConfig.ConsulNamespace,
+		TLSConfig: api.TLSConfig{
+			CAFile:             bootstrapConfig.ConsulTLSCAFile,
+			CertFile:           bootstrapConfig.ConsulTLSCertFile,
+			KeyFile:            bootstrapConfig.ConsulTLSKeyFile,
+			InsecureSkipVerify: bootstrapConfig.ConsulTLSInsecure,
+		},
+	}
+	if bootstrapConfig.ConsulTLSCAFile != "" || bootstrapConfig.ConsulTLSCertFile != "" || bootstrapConfig.ConsulTLSInsecure {
+		consulCfg.Scheme = "https"
+	}
+	return api.NewClient(consulCfg)
+}
+
+// NewApp is like NewAppE but exits the process if any bootstrap stage failed.
+func NewApp(appName string, bootstrapConfig *BootstrapConfig, opts ...Option) *AppStarter {
+	appStarter, err := NewAppE(context.Background(), appName, bootstrapConfig, opts...)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return appStarter
+}
+
+// NewAppE initializes the logger, consul config, consul registry, vault config
+// and env config of the app. The returned error is a *BootstrapError which
+// tells the failed stage, the components created before the failed stage are
+// closed.
+func NewAppE(ctx context.Context, appName string, bootstrapConfig *BootstrapConfig, opts ...Option) (*AppStarter, error) {
+	o := newOptions(opts...)
+
 	if bootstrapConfig == nil {
-		bootstrapConfig = ParseBootstrapConfigEnv()
+		var err error
+		if bootstrapConfig, err = LoadBootstrapConfig(os.Args[1:]); err != nil {
+			return nil, newBootstrapError(StageBootstrap, err)
+		}
 	}
 
+	recorder := newStartupRecorder()
+	if o.metrics != nil {
+		metrics.Enable(o.metrics)
+	}
+
+	// the masker of the starter, the patterns and the loaded secrets do not
+	// change the default masker
+	masker := secret.Default().Clone()
+	masker.AddPatterns(o.secretPatterns...)
+
 	// 初始话 logger
-	logger := appLog.NewLogger()
+	start := time.Now()
+	logger := o.logger
+	if logger == nil {
+		loggerConfig := appLog.DefaultConfig()
+		loggerConfig.Masker = masker
+		var err error
+		if logger, err = appLog.NewLoggerWithConfig(loggerConfig); err != nil {
+			return nil, &BootstrapError{Stage: StageLogger, Err: err, masker: masker}
+		}
+	}
+	recorder.add(StageLogger, start, StatusOK, "", "")
 	logHelper := log.NewHelper(logger)
 
-	// 初始化 consul config
-	logHelper.Info("Start init consul config")
-	client, err := api.NewClient(&api.Config{
-		Address: bootstrapConfig.ConsulAddress,
-		Token:   bootstrapConfig.ConsulToken,
-	})
-	if err != nil {
-		logHelper.Fatal(err)
+	// the config watchers live until Close, not bound to the bootstrap ctx
+	watchCtx, cancelWatch := context.WithCancel(context.Background())
+
+	// created has the components created so far, they are closed by fail
+	created := &AppStarter{Logger: logger, rootLogger: logger, ownLogger: o.logger == nil, ownRegistry: o.registry == nil, cancelWatch: cancelWatch}
+
+	// fail logs the startup report with the failed component, then closes the
+	// created components like Close
+	fail := func(start time.Time, endpoint string, err error) (*AppStarter, error) {
+		var bootstrapErr *BootstrapError
+		if errors.As(err, &bootstrapErr) {
+			bootstrapErr.masker = masker
+		}
+		recorder.fail(start, endpoint, err)
+		recorder.finish(created.Logger)
+		_ = created.Close(context.Background())
+		return nil, err
 	}
 
-	consulSrc, err := consulConfig.New(client, consulConfig.WithPath(fmt.Sprintf("config/%s", appName)))
+	start = time.Now()
+	fileSrcs, err := newFileConfig(bootstrapConfig)
 	if err != nil {
-		logHelper.Fatalf("New consul error: %v", err.Error())
+		return fail(start, bootstrapConfig.ConfigPath, newBootstrapError(StageConfig, err))
 	}
 
-	// 初始化 consul registry
-	var registry *consul.Registry
-	if bootstrapConfig.ConsulTags != "" {
-		registry = consulRegistry.New(client, consul.WithTags(strings.Split(bootstrapConfig.ConsulTags, ",")))
-	} else {
-		registry = consulRegistry.New(client)
+	customSrcs := make([]*trackedSource, 0, len(o.sources))
+	for _, src := range o.sources {
+		customSrcs = append(customSrcs, newTrackedSource(src, SourceCustom, func(key string) string { return key }))
+	}
+	sources := map[SourceKind][]*trackedSource{
+		SourceFile:   fileSrcs,
+		SourceCustom: customSrcs,
+		SourceEnv: {newTrackedSource(env.NewSource(o.envPrefix), SourceEnv, func(key string) string {
+			return o.envPrefix + key
+		})},
 	}
 
-	logHelper.Info("Start init vault config")
+	healthRegistry := health.New()
+
+	registryStart := time.Now()
+	registry := o.registry
+	var consul *consulRegistry.Registry
+	registryEndpoint := "custom"
+	consulReason, vaultReason := "disabled by option", "disabled by option"
+	if bootstrapConfig.Mode == ModeLocal {
+		logHelper.Info("Run in local mode without consul and vault")
+		o.disableConsul = true
+		o.disableVault = true
+		consulReason, vaultReason = "local mode", "local mode"
+		if registry == nil {
+			registry = memoryRegistry.New()
+			registryEndpoint = "memory"
+		}
+	}
 
-	vaultSrc := newVaultConfig(registry, logHelper, appName, bootstrapConfig)
+	if !o.disableConsul {
+		// 初始化 consul config
+		logHelper.Info("Start init consul config")
+		start = time.Now()
+		consulAddr := consulAddress(bootstrapConfig)
+		client, err := newConsulClient(bootstrapConfig)
+		if err != nil {
+			return fail(start, consulAddr, newBootstrapError(StageConsul, err))
+		}
 
-	// 初始化 config
-	configPath := bootstrapConfig.ConfigPath
-	if configPath == "" {
-		configPath = "./conf/application.yaml"
+		consulPath := fmt.Sprintf("config/%s", appName)
+		consulSrc, err := consulConfig.New(client, consulConfig.WithContext(watchCtx), consulConfig.WithPath(consulPath))
+		if err != nil {
+			return fail(start, consulAddr, newBootstrapError(StageConsul, fmt.Errorf("new consul config: %w", err)))
+		}
+		sources[SourceConsul] = []*trackedSource{newTrackedSource(consulSrc, SourceConsul, func(key string) string {
+			return joinOrigin(consulPath, key)
+		})}
+		recorder.add(StageConsul, start, StatusOK, consulAddr, "")
+		healthRegistry.Register("consul", health.Consul(client))
+
+		// 初始化 consul registry
+		if registry == nil {
+			registryStart = time.Now()
+			var registryOpts []consulRegistry.Option
+			if bootstrapConfig.ConsulTags != "" {
+				registryOpts = append(registryOpts, consulRegistry.WithTags(strings.Split(bootstrapConfig.ConsulTags, ",")))
+			}
+			if bootstrapConfig.ConsulHealthCheckPath != "" {
+				registryOpts = append(registryOpts, consulRegistry.WithHealthCheckPath(bootstrapConfig.ConsulHealthCheckPath))
+			}
+			consul = consulRegistry.New(client, registryOpts...)
+			registry = consul
+			registryEndpoint = consulAddr
+		}
+	} else {
+		recorder.skip(StageConsul, consulReason)
+	}
+	created.Registry = registry
+	if registry != nil {
+		recorder.add(StageRegistry, registryStart, StatusOK, registryEndpoint, "")
+	} else {
+		recorder.skip(StageRegistry, "no registry")
 	}
 
+	// vaultPath is set if the vault config is loaded
+	var vaultPath string
+	if !o.disableVault {
+		logHelper.Info("Start init vault config")
+		start = time.Now()
+
+		path := fmt.Sprintf("secret/%s", appName)
+		vaultSrc, vaultClient, err := newVaultConfig(ctx, watchCtx, registry, path, bootstrapConfig)
+		var vaultAddr string
+		if vaultClient != nil {
+			vaultAddr = vaultClient.Address()
+		}
+		if err != nil {
+			return fail(start, vaultAddr, err)
+		}
+		if vaultSrc != nil {
+			healthRegistry.Register("vault", health.Vault(vaultClient))
+			vaultPath = path
+			sources[SourceVault] = []*trackedSource{newTrackedSource(vaultSrc, SourceVault, func(key string) string {
+				return joinOrigin(path, key)
+			})}
+			recorder.add(StageVault, start, StatusOK, vaultAddr, "")
+		} else {
+			recorder.add(StageVault, start, StatusDegraded, "", "vault address not found")
+		}
+	} else {
+		recorder.skip(StageVault, vaultReason)
+	}
+
+	// 初始化 config
+	start = time.Now()
+
+	var trackedSrcs []*trackedSource
 	var configSrcs []config.Source
+	var kinds []string
+	for _, kind := range o.sourceOrder {
+		if len(sources[kind]) > 0 {
+			kinds = append(kinds, string(kind))
+		}
+		for _, src := range sources[kind] {
+			src.masker = masker
+			trackedSrcs = append(trackedSrcs, src)
+			configSrcs = append(configSrcs, src)
+		}
+	}
+
+	cfg := config.New(config.WithSource(configSrcs...))
+	// the watchers of the loaded sources are started even if Load failed
+	created.Config = cfg
 
-	var cfg config.Config
-	if _, err := os.Stat(configPath); !errors.Is(err, os.ErrNotExist) {
-		configSrcs = append(configSrcs, file.NewSource("./conf/application.yaml"))
+	if err := cfg.Load(); err != nil {
+		return fail(start, strings.Join(kinds, ","), newBootstrapError(StageConfig, fmt.Errorf("load config: %w", err)))
 	}
+	recorder.add(StageConfig, start, StatusOK, strings.Join(kinds, ","), "")
 
-	configSrcs = append(configSrcs, consulSrc)
+	// 配置了 log 时按配置重建 logger，WithLogger 指定的 logger 优先
+	if o.logger == nil {
+		start = time.Now()
+		configLogger, endpoint, err := newConfigLogger(cfg, masker)
+		if err != nil {
+			return fail(start, endpoint, newBootstrapError(StageLogger, err))
+		}
+		if configLogger != nil {
+			_ = logger.Close()
+			logger = configLogger
+			created.Logger, created.rootLogger = logger, logger
+			recorder.add(StageLogger, start, StatusOK, endpoint, "")
+		}
+	}
 
-	if vaultSrc != nil {
-		configSrcs = append(configSrcs, vaultSrc)
+	// 初始化 tracing
+	start = time.Now()
+	tracerProvider, tracingEndpoint, err := newTracerProvider(ctx, appName, cfg, o)
+	if err != nil {
+		return fail(start, tracingEndpoint, newBootstrapError(StageTracing, err))
+	}
+	created.TracerProvider = tracerProvider
+	if tracerProvider != nil {
+		recorder.add(StageTracing, start, StatusOK, tracingEndpoint, "")
+	} else {
+		recorder.skip(StageTracing, "no tracing config")
 	}
 
-	configSrcs = append(configSrcs, env.NewSource("APP_"))
+	version := o.version
+	if version == "" {
+		version = buildVersion()
+	}
+	metadata, err := instanceMetadata(cfg, recorder.start)
+	if err != nil {
+		return fail(time.Now(), "", newBootstrapError(StageConfig, err))
+	}
+	for k, v := range o.metadata {
+		metadata[k] = v
+	}
+
+	// 初始化 audit log
+	start = time.Now()
+	auditLogger, auditEndpoint, err := newAuditLogger(cfg, appName)
+	if err != nil {
+		return fail(start, auditEndpoint, newBootstrapError(StageAudit, err))
+	}
+	created.Audit = auditLogger
+	if auditLogger != nil {
+		audit.Enable(auditLogger)
+		recorder.add(StageAudit, start, StatusOK, auditEndpoint, "")
+		if vaultPath != "" {
+			// the vault config is read by cfg.Load before the audit log is created
+			_ = auditLogger.Record(audit.Event{
+				Type:    audit.EventVaultRead,
+				Target:  vaultPath,
+				Details: map[string]string{"stage": "startup"},
+			})
+		}
+	} else {
+		recorder.skip(StageAudit, "no audit config")
+	}
 
-	cfg = config.New(config.WithSource(configSrcs...))
+	rootLogger := logger
+	if tracerProvider != nil {
+		logger = withTraceValuers(logger)
+	}
 
-	if err := cfg.Load(); err != nil {
-		logHelper.Fatalf("App load config error")
+	appStarter := &AppStarter{
+		ID:             uuid.New().String(),
+		Name:           appName,
+		Version:        version,
+		Metadata:       metadata,
+		Logger:         logger,
+		Registry:       registry,
+		ConsulRegistry: consul,
+		Config:         cfg,
+		Health:         healthRegistry,
+		Metrics:        o.metrics,
+		Audit:          auditLogger,
+
+		TracerProvider: tracerProvider,
+		sources:        trackedSrcs,
+		secretMasker:   masker,
+		rootLogger:     rootLogger,
+		ownLogger:      o.logger == nil,
+		ownRegistry:    o.registry == nil,
+		cancelWatch:    cancelWatch,
+	}
+	for _, src := range trackedSrcs {
+		src.setApplied(appStarter.configApplied)
 	}
-	return &AppStarter{
-		Logger:   logger,
-		Registry: registry,
-		Config:   cfg,
+	created = appStarter
+	if err := appStarter.watchLogLevels(); err != nil {
+		return fail(time.Now(), "", newBootstrapError(StageLogger, err))
 	}
+	appStarter.startupReport = recorder.finish(logger)
+	return appStarter, nil
 }
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+	memoryRegistry "github.com/liuxiong332/kratos-starter/registry/memory"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+)
+
+var nopLogger = zapLog.NewLogger(zap.NewNop())
+
+func TestNewAppWithoutConsul(t *testing.T) {
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{},
+		WithLogger(nopLogger),
+		WithoutConsul(),
+		WithConfigSources(memory.New(map[string]interface{}{"server.port": 8000})),
+	)
+	assert.NoError(t, err)
+	assert.Nil(t, appStarter.Registry)
+
+	port, err := appStarter.Config.Value("server.port").Int()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8000, port)
+}
+
+func TestNewAppSourceOrder(t *testing.T) {
+	t.Setenv("TEST_SERVER_PORT", "9000")
+
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{},
+		WithLogger(nopLogger),
+		WithoutConsul(),
+		WithEnvPrefix("TEST_"),
+		WithConfigSources(memory.New(map[string]interface{}{"SERVER_PORT": 8000})),
+		WithSourceOrder(SourceEnv, SourceCustom),
+	)
+	assert.NoError(t, err)
+
+	port, err := appStarter.Config.Value("SERVER_PORT").Int()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8000, port)
+}
+
+func TestNewAppLocalMode(t *testing.T) {
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal},
+		WithLogger(nopLogger),
+		WithConfigSources(memory.New(map[string]interface{}{"server.port": 8000})),
+	)
+	assert.NoError(t, err)
+	assert.IsType(t, &memoryRegistry.Registry{}, appStarter.Registry)
+	assert.Nil(t, appStarter.ConsulRegistry)
+
+	port, err := appStarter.Config.Value("server.port").Int()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8000, port)
+}
@@ -0,0 +1,312 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/liuxiong332/kratos-starter/secret"
+)
+
+var (
+	validateOnce   sync.Once
+	configValidate *validator.Validate
+)
+
+// getValidate returns the validator which names the fields by the json tags.
+func getValidate() *validator.Validate {
+	validateOnce.Do(func() {
+		configValidate = validator.New()
+		configValidate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	})
+	return configValidate
+}
+
+// FieldError is the invalid field of the bound config.
+type FieldError struct {
+	// Key is the config key of the field, like mongo.server
+	Key string
+	// Rule is the failed validate rule, like required
+	Rule  string
+	Param string
+}
+
+func (e FieldError) String() string {
+	if e.Param != "" {
+		return fmt.Sprintf("%s failed on %s=%s", e.Key, e.Rule, e.Param)
+	}
+	return fmt.Sprintf("%s failed on %s", e.Key, e.Rule)
+}
+
+// BindError reports all the invalid fields of the bound config.
+type BindError struct {
+	Key    string
+	Fields []FieldError
+}
+
+func (e *BindError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, f.String())
+	}
+	return fmt.Sprintf("invalid config %s: %s", e.Key, strings.Join(fields, "; "))
+}
+
+// Bind scans the config subtree of the key into T, see BindConfig.
+func Bind[T any](s *AppStarter, key string) (*T, error) {
+	return BindConfig[T](s.Config, key)
+}
+
+// BindConfig scans the config subtree of the key into T, the whole config if
+// the key is empty. The fields missing in the config are set by the default
+// tags, the time.Duration fields accept the duration strings like "3s" or the
+// seconds like Duration, then the validate tags are checked and all the
+// invalid fields are reported in one *BindError.
+func BindConfig[T any](c config.Config, key string) (*T, error) {
+	v := new(T)
+	if err := setDefaults(reflect.ValueOf(v).Elem()); err != nil {
+		return nil, fmt.Errorf("set config %s defaults: %w", key, err)
+	}
+
+	var raw interface{}
+	var err error
+	if key == "" {
+		err = c.Scan(&raw)
+	} else {
+		err = c.Value(key).Scan(&raw)
+	}
+	if err == nil {
+		err = scanValue(raw, v)
+	}
+	if err != nil && !errors.Is(err, config.ErrNotFound) {
+		return nil, fmt.Errorf("scan config %s: %w", key, secret.RedactError(err))
+	}
+
+	if err := validateConfig(key, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// scanValue decodes the config value into v like the config Scan, the
+// time.Duration fields are parsed like Duration first since json decodes
+// time.Duration from the nanoseconds only.
+func scanValue(raw interface{}, v interface{}) error {
+	raw, err := parseDurations(reflect.TypeOf(v), raw)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// parseDurations replaces the values of the time.Duration fields of t in the
+// decoded json value by the nanoseconds. The values are the duration strings
+// or the seconds like Duration.
+func parseDurations(t reflect.Type, raw interface{}) (interface{}, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch value := raw.(type) {
+	case string, float64:
+		if t != durationType {
+			return raw, nil
+		}
+		data, _ := json.Marshal(value)
+		var d Duration
+		if err := d.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return int64(d), nil
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return raw, nil
+		}
+		for i, item := range value {
+			parsed, err := parseDurations(t.Elem(), item)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = parsed
+		}
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for k, item := range value {
+				parsed, err := parseDurations(t.Elem(), item)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", k, err)
+				}
+				value[k] = parsed
+			}
+		case reflect.Struct:
+			for k, item := range value {
+				field, ok := jsonField(t, k)
+				if !ok {
+					continue
+				}
+				parsed, err := parseDurations(field.Type, item)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", k, err)
+				}
+				value[k] = parsed
+			}
+		}
+	}
+	return raw, nil
+}
+
+// jsonField returns the field of the json key, the key is matched case
+// insensitively like json. The embedded structs are not supported.
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func validateConfig(key string, v interface{}) error {
+	if reflect.Indirect(reflect.ValueOf(v)).Kind() != reflect.Struct {
+		return nil
+	}
+	err := getValidate().Struct(v)
+	if err == nil {
+		return nil
+	}
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	bindErr := &BindError{Key: key}
+	for _, fe := range validationErrs {
+		// the namespace starts with the struct name
+		fieldKey := fe.Namespace()
+		if idx := strings.Index(fieldKey, "."); idx >= 0 {
+			fieldKey = fieldKey[idx+1:]
+		}
+		if key != "" {
+			fieldKey = key + "." + fieldKey
+		}
+		bindErr.Fields = append(bindErr.Fields, FieldError{Key: fieldKey, Rule: fe.Tag(), Param: fe.Param()})
+	}
+	return bindErr
+}
+
+// setDefaults sets the fields by the default tags, the nested structs are set
+// recursively.
+func setDefaults(v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if err := setDefault(fv, def); err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Struct {
+			if err := setDefaults(fv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	appDurationType = reflect.TypeOf(Duration(0))
+)
+
+func setDefault(v reflect.Value, def string) error {
+	if v.Type() == durationType || v.Type() == appDurationType {
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(def)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(def)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(def, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(def, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(def, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		items := strings.Split(def, ",")
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setDefault(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported default of type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDBConfig struct {
+	Host     string        `json:"host" validate:"required"`
+	Port     int           `json:"port" default:"5432" validate:"gte=1,lte=65535"`
+	Timeout  time.Duration `json:"timeout" default:"3s"`
+	Replicas []string      `json:"replicas" default:"a,b"`
+	Pool     struct {
+		Size int `json:"size" default:"10" validate:"lte=100"`
+	} `json:"pool"`
+}
+
+func newBindTestApp(t *testing.T, yaml string) *AppStarter {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"application.yaml": yaml})
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: dir},
+		WithLogger(nopLogger),
+	)
+	assert.NoError(t, err)
+	return appStarter
+}
+
+func TestBind(t *testing.T) {
+	appStarter := newBindTestApp(t, "db:\n  host: localhost\n  pool:\n    size: 20\n")
+
+	dbConfig, err := Bind[testDBConfig](appStarter, "db")
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", dbConfig.Host)
+	assert.Equal(t, 5432, dbConfig.Port)
+	assert.Equal(t, 3*time.Second, dbConfig.Timeout)
+	assert.Equal(t, []string{"a", "b"}, dbConfig.Replicas)
+	assert.Equal(t, 20, dbConfig.Pool.Size)
+}
+
+func TestBindDuration(t *testing.T) {
+	appStarter := newBindTestApp(t, "db:\n  host: localhost\n  timeout: 1m30s\nretry:\n  backoffs: [1s, 2s]\n  by_code:\n    unavailable: 500ms\n")
+
+	dbConfig, err := Bind[testDBConfig](appStarter, "db")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, dbConfig.Timeout)
+
+	type retryConfig struct {
+		Backoffs []time.Duration          `json:"backoffs"`
+		ByCode   map[string]time.Duration `json:"by_code"`
+	}
+	retry, err := Bind[retryConfig](appStarter, "retry")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, retry.Backoffs)
+	assert.Equal(t, map[string]time.Duration{"unavailable": 500 * time.Millisecond}, retry.ByCode)
+
+	appStarter = newBindTestApp(t, "db:\n  host: localhost\n  timeout: soon\n")
+	_, err = Bind[testDBConfig](appStarter, "db")
+	assert.ErrorContains(t, err, "scan config db: timeout")
+}
+
+func TestBindDurationSeconds(t *testing.T) {
+	appStarter := newBindTestApp(t, "timeouts:\n  std: 3\n  app: 3\n  std_fraction: 1.5\n  app_fraction: 1.5\n")
+
+	// the bare numbers are the seconds for both duration types
+	type timeoutConfig struct {
+		Std         time.Duration `json:"std"`
+		App         Duration      `json:"app"`
+		StdFraction time.Duration `json:"std_fraction"`
+		AppFraction Duration      `json:"app_fraction"`
+	}
+	timeouts, err := Bind[timeoutConfig](appStarter, "timeouts")
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, timeouts.Std)
+	assert.Equal(t, timeouts.Std, time.Duration(timeouts.App))
+	assert.Equal(t, 1500*time.Millisecond, timeouts.StdFraction)
+	assert.Equal(t, timeouts.StdFraction, time.Duration(timeouts.AppFraction))
+}
+
+func TestBindInvalid(t *testing.T) {
+	appStarter := newBindTestApp(t, "db:\n  port: 70000\n  pool:\n    size: 200\n")
+
+	_, err := Bind[testDBConfig](appStarter, "db")
+	var bindErr *BindError
+	assert.True(t, errors.As(err, &bindErr))
+	assert.Equal(t, []FieldError{
+		{Key: "db.host", Rule: "required"},
+		{Key: "db.port", Rule: "lte", Param: "65535"},
+		{Key: "db.pool.size", Rule: "lte", Param: "100"},
+	}, bindErr.Fields)
+
+	// the missing key is validated as empty
+	_, err = Bind[testDBConfig](appStarter, "missing")
+	assert.ErrorContains(t, err, "missing.host failed on required")
+}
@@ -1,50 +1,237 @@
 package app
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
+// ModeLocal runs the app without consul and vault, only the file and env config
+// and the in-process registry are used.
+const ModeLocal = "local"
+
+// Vault auth methods
+const (
+	VaultAuthToken      = "token"
+	VaultAuthAppRole    = "approle"
+	VaultAuthKubernetes = "kubernetes"
+)
+
+// BootstrapConfig is the config to bootstrap the app starter, it is loaded from
+// the defaults, the env and the flags in order by the struct tags:
+//   - env: the env name
+//   - flag: the flag name
+//   - default: the default value
+//   - required: the value must not be empty
+//   - secret: the value is masked in the help summary
+//   - usage: the flag usage
 type BootstrapConfig struct {
-	ConfigPath    string
-	ConsulAddress string
-	ConsulToken   string
-	ConsulTags    string
-	VaultToken    string
+	Mode       string `env:"APP_MODE" flag:"mode" usage:"App mode, local mode runs without consul and vault"`
+	ConfigPath string `env:"APP_CONFIG_PATH" flag:"config_path" usage:"Comma-separated config files or directories"`
+	Profile    string `env:"APP_PROFILE" flag:"profile" usage:"Config profile, application-{profile}.yaml overrides application.yaml"`
+
+	ConsulAddress     string `env:"APP_CONSUL_ADDRESS" flag:"consul_address" usage:"Consul Address like localhost:8500"`
+	ConsulToken       string `env:"APP_CONSUL_TOKEN" flag:"consul_token" secret:"true" usage:"Consul Token"`
+	ConsulTags        string `env:"APP_CONSUL_TAGS" flag:"consul_tags" usage:"Comma-separated consul registry tags"`
+	ConsulDatacenter  string `env:"APP_CONSUL_DATACENTER" flag:"consul_datacenter" usage:"Consul datacenter"`
+	ConsulNamespace   string `env:"APP_CONSUL_NAMESPACE" flag:"consul_namespace" usage:"Consul namespace"`
+	ConsulTLSCAFile   string `env:"APP_CONSUL_TLS_CA_FILE" flag:"consul_tls_ca_file" usage:"Consul TLS CA file"`
+	ConsulTLSCertFile string `env:"APP_CONSUL_TLS_CERT_FILE" flag:"consul_tls_cert_file" usage:"Consul TLS client cert file"`
+	ConsulTLSKeyFile  string `env:"APP_CONSUL_TLS_KEY_FILE" flag:"consul_tls_key_file" usage:"Consul TLS client key file"`
+	ConsulTLSInsecure bool   `env:"APP_CONSUL_TLS_INSECURE" flag:"consul_tls_insecure" usage:"Skip consul TLS verification"`
+	// ConsulHealthCheckPath is the http path of the consul health check, like the
+	// liveness path /healthz
+	ConsulHealthCheckPath string `env:"APP_CONSUL_HEALTH_CHECK_PATH" flag:"consul_health_check_path" usage:"HTTP path of the consul health check, the tcp check is used if empty"`
+
+	VaultAddress    string `env:"APP_VAULT_ADDRESS" flag:"vault_address" usage:"Vault address, discovered from consul if empty"`
+	VaultToken      string `env:"APP_VAULT_TOKEN" flag:"vault_token" secret:"true" usage:"Vault Token"`
+	VaultAuthMethod string `env:"APP_VAULT_AUTH_METHOD" flag:"vault_auth_method" default:"token" usage:"Vault auth method: token, approle or kubernetes"`
+	VaultRole       string `env:"APP_VAULT_ROLE" flag:"vault_role" usage:"Vault role, the role id of approle or the role of kubernetes auth"`
+	VaultSecretID   string `env:"APP_VAULT_SECRET_ID" flag:"vault_secret_id" secret:"true" usage:"Vault approle secret id"`
 }
 
-func copyIfNotEmpty(str *string, target *string) {
-	if *str != "" {
-		*target = *str
+// LoadBootstrapConfig loads the bootstrap config from the defaults, the env and
+// the args. The args not defined by the bootstrap config are ignored, so the
+// app can define its own flags. flag.ErrHelp is returned if -h or --help is in
+// the args after the help summary is printed to stderr.
+func LoadBootstrapConfig(args []string) (*BootstrapConfig, error) {
+	var config BootstrapConfig
+	if err := loadTagged(&config, os.LookupEnv, args, os.Stderr); err != nil {
+		return nil, err
 	}
+	return &config, nil
 }
 
+// ParseBootstrapConfigEnv loads the bootstrap config from the defaults and the env.
 func ParseBootstrapConfigEnv() *BootstrapConfig {
-	config := BootstrapConfig{
-		os.Getenv("APP_CONFIG_PATH"),
-		os.Getenv("APP_CONSUL_ADDRESS"),
-		os.Getenv("APP_CONSUL_TOKEN"),
-		os.Getenv("APP_CONSUL_TAGS"),
-		os.Getenv("APP_VAULT_TOKEN"),
+	var config BootstrapConfig
+	// only the flag parse may fail, and no args here
+	_ = loadTagged(&config, os.LookupEnv, nil, io.Discard)
+	return &config
+}
+
+// ParseBootstrapConfigFlag overrides the config by the command line flags.
+//
+// Deprecated: use LoadBootstrapConfig instead.
+func ParseBootstrapConfigFlag(config *BootstrapConfig) {
+	fs := newTaggedFlagSet(config, io.Discard)
+	_ = fs.Parse(knownArgs(fs, os.Args[1:]))
+}
+
+// PrintUsage prints the flags, the envs and the current values of the
+// bootstrap config, the secret values are masked.
+func (c *BootstrapConfig) PrintUsage(w io.Writer) {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	fmt.Fprintln(w, "Bootstrap config:")
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := fmt.Sprint(v.Field(i).Interface())
+		if field.Tag.Get("secret") == "true" && value != "" {
+			value = "******"
+		}
+		fmt.Fprintf(w, "  --%s (env %s)\n", field.Tag.Get("flag"), field.Tag.Get("env"))
+		fmt.Fprintf(w, "    \t%s (current %q)\n", field.Tag.Get("usage"), value)
 	}
+}
 
-	if !flag.Parsed() {
-		ParseBootstrapConfigFlag(&config)
+// loadTagged loads the struct pointed by v from the default tags, the env and
+// the args in order, then checks the required tags.
+func loadTagged(v interface{}, lookupEnv func(string) (string, bool), args []string, output io.Writer) error {
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if err := setField(rv.Field(i), def); err != nil {
+				return fmt.Errorf("default of %s: %w", field.Name, err)
+			}
+		}
+		if name := field.Tag.Get("env"); name != "" {
+			if value, ok := lookupEnv(name); ok && value != "" {
+				if err := setField(rv.Field(i), value); err != nil {
+					return fmt.Errorf("env %s: %w", name, err)
+				}
+			}
+		}
 	}
 
-	return &config
+	fs := newTaggedFlagSet(v, output)
+	if err := fs.Parse(knownArgs(fs, args)); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			if c, ok := v.(*BootstrapConfig); ok {
+				c.PrintUsage(output)
+			}
+		}
+		return err
+	}
+
+	var missing []string
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.Tag.Get("required") == "true" && rv.Field(i).IsZero() {
+			missing = append(missing, fmt.Sprintf("%s (env %s, flag --%s)", field.Name, field.Tag.Get("env"), field.Tag.Get("flag")))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required bootstrap config: %s", strings.Join(missing, ", "))
+	}
+	return nil
 }
 
-func ParseBootstrapConfigFlag(config *BootstrapConfig) {
-	configPath := flag.String("config_path", "", "Config path")
-	address := flag.String("consul_address", "", "Consul Address like localhost:8500")
-	token := flag.String("consul_token", "", "Consul Token")
-	vaultToken := flag.String("vault_token", "", "Vault Token")
+// newTaggedFlagSet defines the flags of the struct fields on a new flag set,
+// the flags default to the current field values.
+func newTaggedFlagSet(v interface{}, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {}
 
-	flag.Parse()
+	rv := reflect.ValueOf(v).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name := field.Tag.Get("flag")
+		if name == "" {
+			continue
+		}
+		switch p := rv.Field(i).Addr().Interface().(type) {
+		case *string:
+			fs.StringVar(p, name, *p, field.Tag.Get("usage"))
+		case *bool:
+			fs.BoolVar(p, name, *p, field.Tag.Get("usage"))
+		case *int:
+			fs.IntVar(p, name, *p, field.Tag.Get("usage"))
+		}
+	}
+	return fs
+}
 
-	copyIfNotEmpty(configPath, &config.ConfigPath)
-	copyIfNotEmpty(address, &config.ConsulAddress)
-	copyIfNotEmpty(token, &config.ConsulToken)
-	copyIfNotEmpty(vaultToken, &config.VaultToken)
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// knownArgs returns the args of the flags defined in the flag set and the help
+// flags, other args are dropped.
+func knownArgs(fs *flag.FlagSet, args []string) []string {
+	var known []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		hasValue := strings.Contains(name, "=")
+		if hasValue {
+			name = name[:strings.Index(name, "=")]
+		}
+
+		if name == "h" || name == "help" {
+			known = append(known, arg)
+			continue
+		}
+
+		f := fs.Lookup(name)
+		isBool := false
+		if f != nil {
+			if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok {
+				isBool = bf.IsBoolFlag()
+			}
+			known = append(known, arg)
+		}
+		if hasValue || isBool || i+1 >= len(args) || strings.HasPrefix(args[i+1], "-") {
+			continue
+		}
+		// the next arg is the flag value
+		i++
+		if f != nil {
+			known = append(known, args[i])
+		}
+	}
+	return known
 }
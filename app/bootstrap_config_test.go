@@ -0,0 +1,61 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadBootstrapConfig(t *testing.T) {
+	t.Setenv("APP_CONSUL_ADDRESS", "consul:8500")
+	t.Setenv("APP_CONSUL_TOKEN", "env-token")
+
+	config, err := LoadBootstrapConfig([]string{
+		"--consul_token", "flag-token",
+		"--port", "8000",
+		"-consul_tags=a,b",
+		"--consul_tls_insecure",
+		"--verbose=true",
+		"positional",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "consul:8500", config.ConsulAddress)
+	assert.Equal(t, "flag-token", config.ConsulToken)
+	assert.Equal(t, "a,b", config.ConsulTags)
+	assert.True(t, config.ConsulTLSInsecure)
+	assert.Equal(t, VaultAuthToken, config.VaultAuthMethod)
+
+	// load twice must not panic
+	_, err = LoadBootstrapConfig(nil)
+	assert.NoError(t, err)
+}
+
+func TestLoadTaggedRequired(t *testing.T) {
+	var config struct {
+		Name string `env:"TEST_NAME" flag:"name" required:"true"`
+		Port int    `env:"TEST_PORT" flag:"port" default:"8000"`
+	}
+	lookupEnv := func(name string) (string, bool) {
+		return map[string]string{"TEST_PORT": "9000"}[name], name == "TEST_PORT"
+	}
+
+	err := loadTagged(&config, lookupEnv, nil, io.Discard)
+	assert.ErrorContains(t, err, "Name")
+	assert.Equal(t, 9000, config.Port)
+
+	assert.NoError(t, loadTagged(&config, lookupEnv, []string{"--name", "test"}, io.Discard))
+	assert.Equal(t, "test", config.Name)
+}
+
+func TestBootstrapConfigHelp(t *testing.T) {
+	var out bytes.Buffer
+	config := BootstrapConfig{}
+	err := loadTagged(&config, func(string) (string, bool) { return "", false }, []string{"--vault_token", "secret-token", "--help"}, &out)
+	assert.True(t, errors.Is(err, flag.ErrHelp))
+	assert.Contains(t, out.String(), "--vault_token (env APP_VAULT_TOKEN)")
+	assert.NotContains(t, out.String(), "secret-token")
+}
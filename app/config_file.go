@@ -0,0 +1,134 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/config/file"
+	"github.com/go-kratos/kratos/v2/encoding"
+)
+
+const defaultConfigPath = "./conf/application.yaml"
+
+// profilePath returns the profile overlay of the config file, e.g.
+// conf/application-dev.yaml for conf/application.yaml.
+func profilePath(path string, profile string) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, ext), profile, ext)
+}
+
+func isFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
+
+// fileWithProfile returns the config file and its profile overlay if exists.
+func fileWithProfile(path string, profile string) []string {
+	paths := []string{path}
+	if profile != "" {
+		if overlay := profilePath(path, profile); isFile(overlay) {
+			paths = append(paths, overlay)
+		}
+	}
+	return paths
+}
+
+// dirConfigFiles returns the config files in the directory sorted by name,
+// followed by the overlays of the profile. The overlays of other profiles are
+// skipped, and so are the files without the codec of the extension, like
+// README.md or application.yaml.bak.
+func dirConfigFiles(dir string, profile string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		// the file source decodes the file by the codec of the extension
+		if encoding.GetCodec(strings.TrimPrefix(filepath.Ext(entry.Name()), ".")) == nil {
+			continue
+		}
+		names[entry.Name()] = true
+	}
+
+	var bases, overlays []string
+	for name := range names {
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		if idx := strings.LastIndex(stem, "-"); idx > 0 && names[stem[:idx]+ext] {
+			if stem[idx+1:] == profile {
+				overlays = append(overlays, filepath.Join(dir, name))
+			}
+			continue
+		}
+		bases = append(bases, filepath.Join(dir, name))
+	}
+	sort.Strings(bases)
+	sort.Strings(overlays)
+	return append(bases, overlays...), nil
+}
+
+// configFiles resolves the comma-separated config path to the config files
+// in merge order, the later file overrides the former.
+func configFiles(configPath string, profile string) ([]string, error) {
+	if configPath == "" {
+		if !isFile(defaultConfigPath) {
+			return nil, nil
+		}
+		return fileWithProfile(defaultConfigPath, profile), nil
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, p := range strings.Split(configPath, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		fi, err := os.Stat(p)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("config path %s not exist", p)
+			}
+			return nil, err
+		}
+
+		var files []string
+		if fi.IsDir() {
+			if files, err = dirConfigFiles(p, profile); err != nil {
+				return nil, err
+			}
+		} else {
+			files = fileWithProfile(p, profile)
+		}
+		for _, f := range files {
+			if !seen[f] {
+				seen[f] = true
+				paths = append(paths, f)
+			}
+		}
+	}
+	return paths, nil
+}
+
+func newFileConfig(bootstrapConfig *BootstrapConfig) ([]*trackedSource, error) {
+	paths, err := configFiles(bootstrapConfig.ConfigPath, bootstrapConfig.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]*trackedSource, 0, len(paths))
+	for _, p := range paths {
+		path := p
+		sources = append(sources, newTrackedSource(file.NewSource(path), SourceFile, func(string) string { return path }))
+	}
+	return sources, nil
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml":      "server:\n  port: 8000\n",
+		"application-dev.yaml":  "server:\n  port: 8001\n",
+		"application-prod.yaml": "server:\n  port: 8002\n",
+		"database.yaml":         "db:\n  name: test\n",
+		// not the config files
+		"README.md":            "# config\n",
+		"application.yaml.bak": "server:\n  port: 7000\n",
+		"application.yaml.swp": "\x00",
+		"noext":                "x",
+	})
+	extra := filepath.Join(t.TempDir(), "extra.yaml")
+	writeFiles(t, filepath.Dir(extra), map[string]string{"extra.yaml": "server:\n  port: 9000\n"})
+
+	files, err := configFiles(dir, "dev")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "application.yaml"),
+		filepath.Join(dir, "database.yaml"),
+		filepath.Join(dir, "application-dev.yaml"),
+	}, files)
+
+	files, err = configFiles(filepath.Join(dir, "application.yaml")+", "+extra, "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "application.yaml"),
+		filepath.Join(dir, "application-prod.yaml"),
+		extra,
+	}, files)
+
+	_, err = configFiles(filepath.Join(dir, "missing.yaml"), "")
+	assert.Error(t, err)
+}
+
+func TestNewAppConfigPath(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml":     "server:\n  port: 8000\n  name: test\n",
+		"application-dev.yaml": "server:\n  port: 8001\n",
+	})
+
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: dir, Profile: "dev"},
+		WithLogger(nopLogger),
+	)
+	assert.NoError(t, err)
+
+	port, err := appStarter.Config.Value("server.port").Int()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8001, port)
+	name, err := appStarter.Config.Value("server.name").String()
+	assert.NoError(t, err)
+	assert.Equal(t, "test", name)
+
+	_, err = NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: filepath.Join(dir, "missing.yaml")},
+		WithLogger(nopLogger),
+	)
+	assert.True(t, IsStage(err, StageConfig))
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/liuxiong332/kratos-starter/secret"
+)
+
+// Stage is the bootstrap stage of the app starter.
+type Stage string
+
+const (
+	StageBootstrap Stage = "bootstrap"
+	StageLogger    Stage = "logger"
+	StageConsul    Stage = "consul"
+	StageRegistry  Stage = "registry"
+	StageVault     Stage = "vault"
+	StageConfig    Stage = "config"
+	StageTracing   Stage = "tracing"
+	StageAudit     Stage = "audit"
+)
+
+// BootstrapError is returned by NewAppE when one bootstrap stage failed.
+type BootstrapError struct {
+	Stage Stage
+	Err   error
+	// masker is the masker of the starter, default is secret.Default()
+	masker *secret.Masker
+}
+
+func newBootstrapError(stage Stage, err error) error {
+	return &BootstrapError{Stage: stage, Err: err}
+}
+
+func (e *BootstrapError) Error() string {
+	masker := e.masker
+	if masker == nil {
+		masker = secret.Default()
+	}
+	return masker.Redact(fmt.Sprintf("app bootstrap %s: %v", e.Stage, e.Err))
+}
+
+func (e *BootstrapError) Unwrap() error {
+	return e.Err
+}
+
+// IsStage reports whether err is raised by the given bootstrap stage.
+func IsStage(err error, stage Stage) bool {
+	var bootstrapErr *BootstrapError
+	if errors.As(err, &bootstrapErr) {
+		return bootstrapErr.Stage == stage
+	}
+	return false
+}
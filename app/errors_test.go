@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMain runs the tests in a temp dir, the default logger writes
+// ./logs/elk.log before the log config is loaded.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "app-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestIsStage(t *testing.T) {
+	cause := errors.New("sealed")
+	err := fmt.Errorf("start: %w", newBootstrapError(StageVault, cause))
+	assert.True(t, IsStage(err, StageVault))
+	assert.False(t, IsStage(err, StageConsul))
+	assert.False(t, IsStage(cause, StageVault))
+	assert.True(t, errors.Is(err, cause))
+	assert.Equal(t, "app bootstrap vault: sealed", errors.Unwrap(err).Error())
+}
+
+func TestNewAppEStage(t *testing.T) {
+	// the consul client fails to load the CA file
+	t.Setenv("CONSUL_CACERT", filepath.Join(t.TempDir(), "missing.pem"))
+	_, err := NewAppE(context.Background(), "test", &BootstrapConfig{ConsulAddress: "https://127.0.0.1:8501", ConfigPath: t.TempDir()})
+	assert.True(t, IsStage(err, StageConsul))
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"runtime/debug"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// buildVersion returns the main module version, or the vcs revision if the
+// app is built from the source tree.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	revision := buildSetting("vcs.revision")
+	if len(revision) > 12 {
+		return revision[:12]
+	}
+	return revision
+}
+
+// buildSetting returns the build setting of the key like vcs.revision, empty if
+// not found.
+func buildSetting(key string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == key {
+			return setting.Value
+		}
+	}
+	return ""
+}
+
+// NewKratosApp creates the kratos app with the name, version, instance id,
+// metadata, logger, registrar, servers and lifecycle hooks of the starter.
+// The opts override the starter defaults.
+func (s *AppStarter) NewKratosApp(opts ...kratos.Option) *kratos.App {
+	appOpts := []kratos.Option{
+		kratos.ID(s.ID),
+		kratos.Name(s.Name),
+		kratos.Version(s.Version),
+		kratos.Metadata(s.Metadata),
+		kratos.Logger(s.Logger),
+		kratos.Server(s.Servers()...),
+	}
+	if s.Registry != nil {
+		appOpts = append(appOpts, kratos.Registrar(s.Registry))
+	}
+	appOpts = append(appOpts, s.LifecycleOptions()...)
+	return kratos.New(append(appOpts, opts...)...)
+}
+
+// Run creates the kratos app with the starter servers and the servers, then
+// runs it until the app stops.
+func (s *AppStarter) Run(servers ...transport.Server) error {
+	return s.NewKratosApp(kratos.Server(append(s.Servers(), servers...)...)).Run()
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+)
+
+func TestNewKratosApp(t *testing.T) {
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal},
+		WithLogger(nopLogger),
+		WithVersion("v1.0.0"),
+		WithMetadata(map[string]string{"zone": "a"}),
+	)
+	assert.NoError(t, err)
+
+	app := appStarter.NewKratosApp()
+	assert.Equal(t, appStarter.ID, app.ID())
+	assert.Equal(t, "test", app.Name())
+	assert.Equal(t, "v1.0.0", app.Version())
+	assert.Equal(t, "a", app.Metadata()["zone"])
+
+	app = appStarter.NewKratosApp(kratos.Name("override"))
+	assert.Equal(t, "override", app.Name())
+}
+
+func TestInstanceMetadata(t *testing.T) {
+	t.Setenv("POD_NAME", "test-0")
+	BuildTime = "2024-01-02T03:04:05Z"
+	defer func() { BuildTime = "" }()
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithLogger(nopLogger),
+		WithConfigSources(memory.New(map[string]interface{}{
+			"instance.zone":          "a",
+			"instance.weight":        10,
+			"instance.metadata.team": "infra",
+		})),
+		WithMetadata(map[string]string{MetadataZone: "b"}),
+	)
+	assert.NoError(t, err)
+
+	hostname, _ := os.Hostname()
+	assert.Equal(t, hostname, appStarter.Metadata[MetadataHostname])
+	assert.Equal(t, "test-0", appStarter.Metadata[MetadataPodName])
+	assert.NotContains(t, appStarter.Metadata, MetadataPodNamespace)
+	assert.Equal(t, "b", appStarter.Metadata[MetadataZone])
+	assert.Equal(t, "10", appStarter.Metadata[MetadataWeight])
+	assert.Equal(t, "infra", appStarter.Metadata["team"])
+	assert.Equal(t, "2024-01-02T03:04:05Z", appStarter.Metadata[MetadataBuildTime])
+	_, err = time.Parse(time.RFC3339, appStarter.Metadata[MetadataStartTime])
+	assert.NoError(t, err)
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/liuxiong332/kratos-starter/logger/audit"
+)
+
+// Close shuts down the starter components in order: stops the config watchers,
+// cancels the registry resolution, flushes the spans, closes the audit log,
+// then flushes the logs and closes the log files. The logger and the registry
+// passed by WithLogger and WithRegistry are not closed. The first error is
+// returned, Close is safe to call more than once.
+func (s *AppStarter) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		var errs []error
+		if s.Config != nil {
+			if err := s.Config.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close config: %w", err))
+			}
+		}
+		if s.cancelWatch != nil {
+			s.cancelWatch()
+		}
+		if closer, ok := s.Registry.(interface{ Close() error }); ok && s.ownRegistry {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close registry: %w", err))
+			}
+		}
+		if s.TracerProvider != nil {
+			if err := s.TracerProvider.Shutdown(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("shutdown tracer provider: %w", err))
+			}
+		}
+
+		if err := s.closeAudit(); err != nil {
+			errs = append(errs, fmt.Errorf("close audit log: %w", err))
+		}
+
+		logHelper := log.NewHelper(s.Logger)
+		for _, err := range errs {
+			logHelper.Errorf("Close app starter error: %v", err)
+		}
+		rootLogger := s.rootLogger
+		if rootLogger == nil {
+			rootLogger = s.Logger
+		}
+		if s.ownLogger {
+			if err := rootLogger.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close logger: %w", err))
+			}
+		} else {
+			_ = rootLogger.Sync()
+		}
+		if len(errs) > 0 {
+			s.closeErr = errs[0]
+		}
+	})
+	return s.closeErr
+}
+
+// closeAudit disables and closes the audit log of the starter.
+func (s *AppStarter) closeAudit() error {
+	if s.Audit == nil {
+		return nil
+	}
+	if audit.Default() == s.Audit {
+		audit.Enable(nil)
+	}
+	return s.Audit.Close()
+}
+
+// BeforeStop flushes the buffered logs before the kratos app stops.
+func (s *AppStarter) BeforeStop(ctx context.Context) error {
+	log.NewHelper(s.Logger).Info("App is stopping")
+	_ = s.Logger.Sync()
+	return nil
+}
+
+// AfterStop closes the starter after the kratos app stopped.
+func (s *AppStarter) AfterStop(ctx context.Context) error {
+	return s.Close(ctx)
+}
+
+// LifecycleOptions returns the kratos options to hook the starter to the app lifecycle.
+func (s *AppStarter) LifecycleOptions() []kratos.Option {
+	return []kratos.Option{
+		kratos.BeforeStop(s.BeforeStop),
+		kratos.AfterStop(s.AfterStop),
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+)
+
+type closeRecorder struct {
+	closed int
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestAppStarterClose(t *testing.T) {
+	recorder := &closeRecorder{}
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal},
+		WithLogger(zapLog.NewLogger(zap.NewNop(), zapLog.WithCloser(recorder))),
+	)
+	assert.NoError(t, err)
+
+	assert.Len(t, appStarter.LifecycleOptions(), 2)
+	assert.NoError(t, appStarter.BeforeStop(context.Background()))
+	assert.NoError(t, appStarter.AfterStop(context.Background()))
+	assert.NoError(t, appStarter.Close(context.Background()))
+	// the logger passed by WithLogger is closed by the caller
+	assert.Equal(t, 0, recorder.closed)
+	assert.NoError(t, appStarter.Logger.Close())
+	assert.Equal(t, 1, recorder.closed)
+}
+
+func TestAppStarterCloseCancelWatch(t *testing.T) {
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal},
+		WithLogger(zapLog.NewLogger(zap.NewNop())),
+	)
+	assert.NoError(t, err)
+	assert.NotNil(t, appStarter.cancelWatch)
+	assert.False(t, appStarter.ownLogger)
+	assert.True(t, appStarter.ownRegistry)
+	assert.NoError(t, appStarter.Close(context.Background()))
+}
+
+// stopSource counts the stopped watchers of the source.
+type stopSource struct {
+	config.Source
+	stopped int32
+}
+
+func (s *stopSource) Watch() (config.Watcher, error) {
+	w, err := s.Source.Watch()
+	if err != nil {
+		return nil, err
+	}
+	return &stopWatcher{Watcher: w, source: s}, nil
+}
+
+type stopWatcher struct {
+	config.Watcher
+	source *stopSource
+}
+
+func (w *stopWatcher) Stop() error {
+	atomic.AddInt32(&w.source.stopped, 1)
+	return w.Watcher.Stop()
+}
+
+func TestNewAppFailClose(t *testing.T) {
+	recorder := &closeRecorder{}
+	src := &stopSource{Source: memory.New(map[string]interface{}{"tracing.sampler_ratio": 2})}
+	_, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithLogger(zapLog.NewLogger(zap.NewNop(), zapLog.WithCloser(recorder))),
+		WithConfigSources(src),
+	)
+	assert.True(t, IsStage(err, StageTracing))
+
+	// the config watchers created before the failed stage are stopped, the
+	// logger passed by WithLogger is left open
+	assert.EqualValues(t, 1, atomic.LoadInt32(&src.stopped))
+	assert.Equal(t, 0, recorder.closed)
+}
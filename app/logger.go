@@ -0,0 +1,83 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+
+	appLog "github.com/liuxiong332/kratos-starter/logger"
+	"github.com/liuxiong332/kratos-starter/logger/audit"
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+	"github.com/liuxiong332/kratos-starter/secret"
+)
+
+// newConfigLogger creates the logger by the log config redacted by the
+// masker, the logger is nil if the log config is not found. The endpoint is the outputs of the logger.
+func newConfigLogger(cfg config.Config, masker *secret.Masker) (logger *zapLog.Logger, endpoint string, err error) {
+	if cfg.Value("log").Load() == nil {
+		return nil, "", nil
+	}
+	loggerConfig, err := appLog.ParseLoggerConfig(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	loggerConfig.Masker = masker
+	endpoint = strings.Join(loggerConfig.OutputNames(), ",")
+	logger, err = appLog.NewLoggerWithConfig(loggerConfig)
+	return logger, endpoint, err
+}
+
+// newAuditLogger creates the audit logger by the audit config, the logger is
+// nil if the audit config is not found. The actor defaults to the app name.
+func newAuditLogger(cfg config.Config, appName string) (logger *audit.Logger, endpoint string, err error) {
+	if cfg.Value("audit").Load() == nil {
+		return nil, "", nil
+	}
+	auditConfig, err := audit.ParseConfig(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	if auditConfig.Actor == "" {
+		auditConfig.Actor = appName
+	}
+	endpoint = strings.Join((&appLog.LoggerConfig{Outputs: auditConfig.Outputs}).OutputNames(), ",")
+	logger, err = audit.New(auditConfig)
+	return logger, endpoint, err
+}
+
+// watchLogLevels updates the levels of the logger when the log.level or
+// log.modules config changes, the keys not found at startup are applied once
+// created, like the level first set in the consul KV.
+func (s *AppStarter) watchLogLevels() error {
+	levels := s.Logger.Levels()
+	if levels == nil {
+		return nil
+	}
+	update := func() {
+		if err := appLog.UpdateLevels(levels, s.Config); err != nil {
+			log.NewHelper(s.Logger).Errorf("Reject log levels update: %v", err)
+			return
+		}
+		log.NewHelper(s.Logger).Infof("Log level changed to %s", levels.Level(""))
+	}
+	for _, key := range []string{"log.level", "log.modules"} {
+		if err := s.observe(key, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LevelHandler returns the admin HTTP handler which gets and overrides the
+// logger levels, see logger.LevelHandler. It changes the levels without
+// authentication, so mount it at logger.LevelPath of the admin server rather
+// than the public one. It responds 404 if the logger has no levels.
+func (s *AppStarter) LevelHandler() http.Handler {
+	levels := s.Logger.Levels()
+	if levels == nil {
+		return http.NotFoundHandler()
+	}
+	return appLog.LevelHandler(levels)
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+	appLog "github.com/liuxiong332/kratos-starter/logger"
+)
+
+func TestWatchLogLevels(t *testing.T) {
+	src := memory.New(map[string]interface{}{
+		"log.level":          "info",
+		"log.modules.consul": "warn",
+		"log.outputs":        []interface{}{map[string]interface{}{"type": "stderr"}},
+	})
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithConfigSources(src),
+	)
+	assert.NoError(t, err)
+	defer appStarter.Close(context.Background())
+
+	levels := appStarter.Logger.Levels()
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("consul"))
+
+	src.Set(map[string]interface{}{"log.level": "debug", "log.modules.consul": "error"})
+	assert.Eventually(t, func() bool {
+		return levels.Level("") == zapcore.DebugLevel && levels.Level("consul") == zapcore.ErrorLevel
+	}, time.Second*5, time.Millisecond*10)
+
+	// the invalid level is rejected
+	src.Set(map[string]interface{}{"log.level": "verbose"})
+	time.Sleep(time.Millisecond * 200)
+	assert.Equal(t, zapcore.DebugLevel, levels.Level(""))
+}
+
+func TestWatchLogLevelsCreated(t *testing.T) {
+	// no log.level and log.modules at startup, like the consul KV not set yet
+	src := memory.New(map[string]interface{}{"server.port": 8000})
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithConfigSources(src),
+	)
+	assert.NoError(t, err)
+	defer appStarter.Close(context.Background())
+
+	levels := appStarter.Logger.Levels()
+	assert.Equal(t, zapcore.InfoLevel, levels.Level(""))
+
+	src.Set(map[string]interface{}{"log.level": "debug", "log.modules.consul": "error"})
+	assert.Eventually(t, func() bool {
+		return levels.Level("") == zapcore.DebugLevel && levels.Level("consul") == zapcore.ErrorLevel
+	}, time.Second*5, time.Millisecond*10)
+
+	// the created keys are watched
+	src.Set(map[string]interface{}{"log.level": "warn"})
+	assert.Eventually(t, func() bool {
+		return levels.Level("") == zapcore.WarnLevel
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestLevelHandler(t *testing.T) {
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()})
+	assert.NoError(t, err)
+	defer appStarter.Close(context.Background())
+
+	// the handler is not mounted on the public server
+	httpSrv, err := appStarter.NewHTTPServer()
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	httpSrv.ServeHTTP(w, httptest.NewRequest("GET", appLog.LevelPath, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	appStarter.LevelHandler().ServeHTTP(w, httptest.NewRequest("PUT", appLog.LevelPath, strings.NewReader(`{"level":"debug"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, zapcore.DebugLevel, appStarter.Logger.Levels().Level(""))
+}
+
+func TestLoggerMasker(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithSecretPatterns("*passwd*"),
+		WithConfigSources(memory.New(map[string]interface{}{
+			"db.passwd":   "s3cr3t-pwd",
+			"log.outputs": []interface{}{map[string]interface{}{"type": "file", "path": logPath}},
+		})),
+	)
+	assert.NoError(t, err)
+	log.NewHelper(appStarter.Logger).Infof("connect with %s", "s3cr3t-pwd")
+	assert.NoError(t, appStarter.Close(context.Background()))
+
+	// the secret of the starter is redacted by the logger of the starter
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "connect with ******")
+}
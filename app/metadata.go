@@ -0,0 +1,73 @@
+package app
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
+// Metadata keys of the instance registered to the registry
+const (
+	MetadataHostname     = "hostname"
+	MetadataPodName      = "pod_name"
+	MetadataPodNamespace = "pod_namespace"
+	MetadataGitCommit    = "git_commit"
+	// MetadataCommitTime is the time of the git commit
+	MetadataCommitTime = "commit_time"
+	// MetadataBuildTime is the BuildTime set by the linker
+	MetadataBuildTime = "build_time"
+	MetadataZone      = "zone"
+	// MetadataWeight is the weight of the kratos wrr selector
+	MetadataWeight    = "weight"
+	MetadataStartTime = "start_time"
+)
+
+// BuildTime is the build time of the app set by the linker, like
+// -ldflags "-X github.com/liuxiong332/kratos-starter/app.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)".
+var BuildTime string
+
+// InstanceConfig is the config of the instance block.
+type InstanceConfig struct {
+	Zone   string `json:"zone"`
+	Weight int    `json:"weight" validate:"min=0"`
+	// Metadata is the extra metadata of the instance
+	Metadata map[string]string `json:"metadata"`
+}
+
+// instanceMetadata returns the metadata of the instance: the hostname, the pod
+// name and namespace from the POD_NAME and POD_NAMESPACE env, the git commit
+// and commit time from the build info, the BuildTime, the zone, weight and
+// extra metadata from the instance config, and the start time. The empty values are skipped.
+func instanceMetadata(cfg config.Config, startTime time.Time) (map[string]string, error) {
+	instanceConfig, err := BindConfig[InstanceConfig](cfg, "instance")
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{
+		MetadataPodName:      os.Getenv("POD_NAME"),
+		MetadataPodNamespace: os.Getenv("POD_NAMESPACE"),
+		MetadataGitCommit:    buildSetting("vcs.revision"),
+		MetadataCommitTime:   buildSetting("vcs.time"),
+		MetadataBuildTime:    BuildTime,
+		MetadataZone:         instanceConfig.Zone,
+		MetadataStartTime:    startTime.UTC().Format(time.RFC3339),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		metadata[MetadataHostname] = hostname
+	}
+	if instanceConfig.Weight > 0 {
+		metadata[MetadataWeight] = strconv.Itoa(instanceConfig.Weight)
+	}
+	for k, v := range instanceConfig.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range metadata {
+		if v == "" {
+			delete(metadata, k)
+		}
+	}
+	return metadata, nil
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/registry"
+
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+	"github.com/liuxiong332/kratos-starter/metrics"
+	"github.com/liuxiong332/kratos-starter/tracing"
+)
+
+// Registry is the service registrar and discovery used by the app starter.
+type Registry interface {
+	registry.Registrar
+	registry.Discovery
+}
+
+// SourceKind is the kind of config source, used to order the config sources.
+type SourceKind string
+
+const (
+	SourceFile   SourceKind = "file"
+	SourceConsul SourceKind = "consul"
+	SourceVault  SourceKind = "vault"
+	SourceCustom SourceKind = "custom"
+	SourceEnv    SourceKind = "env"
+)
+
+// defaultSourceOrder is the config source order, later source overrides the former.
+var defaultSourceOrder = []SourceKind{SourceFile, SourceConsul, SourceVault, SourceCustom, SourceEnv}
+
+// Option is app starter option.
+type Option func(o *options)
+
+type options struct {
+	version       string
+	metadata      map[string]string
+	logger        *zapLog.Logger
+	registry      Registry
+	sources       []config.Source
+	disableConsul bool
+	disableVault  bool
+	envPrefix     string
+	sourceOrder   []SourceKind
+
+	secretPatterns []string
+	metrics        *metrics.Metrics
+
+	tracing     bool
+	tracingOpts []tracing.Option
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		envPrefix:   "APP_",
+		sourceOrder: defaultSourceOrder,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithVersion with the app version, default is the version from the build info.
+func WithVersion(version string) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// WithMetadata with the app metadata registered to the registry, it overrides
+// the instance metadata of the starter.
+func WithMetadata(md map[string]string) Option {
+	return func(o *options) {
+		o.metadata = md
+	}
+}
+
+// WithLogger with the logger instead of the default zap logger.
+func WithLogger(logger *zapLog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithRegistry with the registry instead of the consul registry.
+// The registry is also used to discover the vault service.
+func WithRegistry(r Registry) Option {
+	return func(o *options) {
+		o.registry = r
+	}
+}
+
+// WithConfigSources with the extra config sources, loaded at SourceCustom order.
+func WithConfigSources(sources ...config.Source) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, sources...)
+	}
+}
+
+// WithoutVault disables the vault config source.
+func WithoutVault() Option {
+	return func(o *options) {
+		o.disableVault = true
+	}
+}
+
+// WithoutConsul disables the consul config source and the consul registry.
+func WithoutConsul() Option {
+	return func(o *options) {
+		o.disableConsul = true
+	}
+}
+
+// WithEnvPrefix with the env config source prefix, default is APP_.
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithSourceOrder with the config source order, later source overrides the former.
+// The source kind not in the order is not loaded.
+func WithSourceOrder(order ...SourceKind) Option {
+	return func(o *options) {
+		o.sourceOrder = order
+	}
+}
+
+// WithSecretPatterns with the extra patterns of the secret config keys, like
+// *passwd*. The secret values are masked in the config dumps, the logs and the
+// error messages.
+func WithSecretPatterns(patterns ...string) Option {
+	return func(o *options) {
+		o.secretPatterns = append(o.secretPatterns, patterns...)
+	}
+}
+
+// WithMetrics enables the prometheus metrics of the starter components, the
+// /metrics handler is mounted on the server created by NewHTTPServer.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithTracing enables the tracing even if no tracing config, the tracer
+// provider is created from the tracing config and the opts.
+func WithTracing(opts ...tracing.Option) Option {
+	return func(o *options) {
+		o.tracing = true
+		o.tracingOpts = append(o.tracingOpts, opts...)
+	}
+}
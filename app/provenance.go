@@ -0,0 +1,242 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/encoding"
+
+	"github.com/liuxiong332/kratos-starter/metrics"
+	"github.com/liuxiong332/kratos-starter/secret"
+)
+
+// trackedSource records the last loaded kvs of the source to tell the
+// provenance of the config keys.
+type trackedSource struct {
+	config.Source
+	kind SourceKind
+	// origin returns where the kv comes from, like the consul key path
+	origin func(kvKey string) string
+	masker *secret.Masker
+
+	kvs  []*config.KeyValue
+	lock sync.RWMutex
+	// applied is called after the change of the source is applied
+	applied func()
+}
+
+func newTrackedSource(src config.Source, kind SourceKind, origin func(kvKey string) string) *trackedSource {
+	return &trackedSource{Source: src, kind: kind, origin: origin}
+}
+
+func (s *trackedSource) record(kvs []*config.KeyValue) {
+	s.lock.Lock()
+	s.kvs = kvs
+	s.lock.Unlock()
+	s.markSecrets()
+}
+
+func (s *trackedSource) setApplied(fn func()) {
+	s.lock.Lock()
+	s.applied = fn
+	s.lock.Unlock()
+}
+
+func (s *trackedSource) notifyApplied() {
+	s.lock.RLock()
+	applied := s.applied
+	s.lock.RUnlock()
+	if applied != nil {
+		applied()
+	}
+}
+
+func (s *trackedSource) Load() ([]*config.KeyValue, error) {
+	kvs, err := s.Source.Load()
+	if err == nil {
+		s.record(kvs)
+	}
+	return kvs, err
+}
+
+func (s *trackedSource) Watch() (config.Watcher, error) {
+	w, err := s.Source.Watch()
+	if err != nil {
+		return nil, err
+	}
+	return &trackedWatcher{Watcher: w, source: s}, nil
+}
+
+// walk calls fn with the flattened config keys, the values and the origins of
+// the last loaded kvs.
+func (s *trackedSource) walk(fn func(key string, value interface{}, origin string)) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, kv := range s.kvs {
+		if kv.Format == "" {
+			fn(kv.Key, string(kv.Value), s.origin(kv.Key))
+			continue
+		}
+		codec := encoding.GetCodec(kv.Format)
+		if codec == nil {
+			continue
+		}
+		var decoded map[string]interface{}
+		if err := codec.Unmarshal(kv.Value, &decoded); err != nil {
+			continue
+		}
+		flat := make(map[string]interface{})
+		flattenValue("", convertValue(decoded), flat)
+		for key, value := range flat {
+			fn(key, value, s.origin(kv.Key))
+		}
+	}
+}
+
+// origins returns the flattened config keys of the source and their origins.
+func (s *trackedSource) origins() map[string]string {
+	origins := make(map[string]string)
+	s.walk(func(key string, _ interface{}, origin string) {
+		origins[key] = origin
+	})
+	return origins
+}
+
+// markSecrets marks the keys and values of the vault source and the secret
+// keys of other sources as secret.
+func (s *trackedSource) markSecrets() {
+	if s.masker == nil {
+		return
+	}
+	s.walk(func(key string, value interface{}, _ string) {
+		if s.kind == SourceVault || s.masker.IsSecretKey(key) {
+			s.masker.MarkKey(key)
+			s.masker.MarkValue(fmt.Sprint(value))
+		}
+	})
+}
+
+type trackedWatcher struct {
+	config.Watcher
+	source *trackedSource
+	// changed is true if the last kvs are returned, the kratos config applies
+	// them before calling Next again
+	changed bool
+}
+
+func (w *trackedWatcher) Next() ([]*config.KeyValue, error) {
+	if w.changed {
+		w.changed = false
+		w.source.notifyApplied()
+	}
+	kvs, err := w.Watcher.Next()
+	if err == nil && kvs != nil {
+		w.changed = true
+		w.source.record(kvs)
+		metrics.Default().ConfigReloaded(string(w.source.kind), nil)
+	} else if err != nil && !errors.Is(err, context.Canceled) {
+		metrics.Default().ConfigReloaded(string(w.source.kind), err)
+	}
+	return kvs, err
+}
+
+// convertValue converts the map[interface{}]interface{} decoded by yaml to
+// map[string]interface{}.
+func convertValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = convertValue(item)
+		}
+		return value
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			m[fmt.Sprint(key)] = convertValue(item)
+		}
+		return m
+	default:
+		return v
+	}
+}
+
+// ConfigEntry describes the effective value of the config key and the source
+// it comes from.
+type ConfigEntry struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	// Source is the source kind, like consul
+	Source SourceKind `json:"source,omitempty"`
+	// Origin is where the value comes from in the source, like the file
+	// path, the consul key path, the vault path or the env name
+	Origin string `json:"origin,omitempty"`
+	Secret bool   `json:"secret"`
+}
+
+// DescribeConfig returns the effective config sorted by key, the source of the
+// key is the last source which has the key in the source order. The keys from
+// vault or matching the secret patterns are secret, but the values are
+// returned as is.
+func (s *AppStarter) DescribeConfig() ([]ConfigEntry, error) {
+	var values map[string]interface{}
+	if err := s.Config.Scan(&values); err != nil {
+		return nil, err
+	}
+	flat := make(map[string]interface{})
+	flattenValue("", values, flat)
+
+	sourceOrigins := make([]map[string]string, len(s.sources))
+	for i, src := range s.sources {
+		sourceOrigins[i] = src.origins()
+	}
+
+	entries := make([]ConfigEntry, 0, len(flat))
+	for key, value := range flat {
+		entry := ConfigEntry{Key: key, Value: value}
+		for i := len(s.sources) - 1; i >= 0; i-- {
+			if origin, ok := sourceOrigins[i][key]; ok {
+				entry.Source = s.sources[i].kind
+				entry.Origin = origin
+				break
+			}
+		}
+		entry.Secret = entry.Source == SourceVault || s.masker().IsSecretKey(key)
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries, nil
+}
+
+// ConfigHandler returns the admin HTTP handler which prints the effective
+// config and its provenance as json, the secret values are masked.
+func (s *AppStarter) ConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries, err := s.DescribeConfig()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i := range entries {
+			if entries[i].Secret {
+				entries[i].Value = secret.Mask
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(entries)
+	})
+}
+
+func joinOrigin(prefix string, key string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + key
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+	"github.com/liuxiong332/kratos-starter/secret"
+)
+
+func TestDescribeConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml": "server:\n  port: 8000\n  name: test\n",
+	})
+	t.Setenv("APP_SERVER_MODE", "debug")
+
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: dir},
+		WithLogger(nopLogger),
+		WithConfigSources(memory.New(map[string]interface{}{"server.port": 9000})),
+	)
+	assert.NoError(t, err)
+
+	entries, err := appStarter.DescribeConfig()
+	assert.NoError(t, err)
+	byKey := make(map[string]ConfigEntry)
+	for _, entry := range entries {
+		byKey[entry.Key] = entry
+	}
+
+	assert.Equal(t, ConfigEntry{Key: "server.name", Value: "test", Source: SourceFile, Origin: filepath.Join(dir, "application.yaml")}, byKey["server.name"])
+	assert.Equal(t, ConfigEntry{Key: "server.port", Value: float64(9000), Source: SourceCustom, Origin: "server.port"}, byKey["server.port"])
+	assert.Equal(t, ConfigEntry{Key: "SERVER_MODE", Value: "debug", Source: SourceEnv, Origin: "APP_SERVER_MODE"}, byKey["SERVER_MODE"])
+}
+
+func TestConfigHandler(t *testing.T) {
+	sources := []*trackedSource{
+		newTrackedSource(memory.New(map[string]interface{}{"db.user": "user"}), SourceCustom, func(key string) string { return key }),
+		newTrackedSource(memory.New(map[string]interface{}{"db.password": "pwd"}), SourceVault, func(key string) string {
+			return joinOrigin("secret/test", key)
+		}),
+	}
+	cfg := config.New(config.WithSource(sources[0], sources[1]))
+	assert.NoError(t, cfg.Load())
+	appStarter := &AppStarter{Logger: nopLogger, Config: cfg, sources: sources}
+
+	w := httptest.NewRecorder()
+	appStarter.ConfigHandler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/config", nil))
+
+	var entries []ConfigEntry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	assert.Equal(t, []ConfigEntry{
+		{Key: "db.password", Value: secret.Mask, Source: SourceVault, Origin: "secret/test/db.password", Secret: true},
+		{Key: "db.user", Value: "user", Source: SourceCustom, Origin: "db.user"},
+	}, entries)
+}
+
+func TestDescribeConfigSecretPatterns(t *testing.T) {
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithLogger(nopLogger),
+		WithSecretPatterns("*passwd*"),
+		WithConfigSources(memory.New(map[string]interface{}{"db.passwd": "s3cr3t-pwd", "db.user": "user"})),
+	)
+	assert.NoError(t, err)
+
+	entries, err := appStarter.DescribeConfig()
+	assert.NoError(t, err)
+	secrets := make(map[string]bool)
+	for _, entry := range entries {
+		secrets[entry.Key] = entry.Secret
+	}
+	assert.Equal(t, map[string]bool{"db.passwd": true, "db.user": false}, secrets)
+
+	// the loaded secret value is redacted in the error messages
+	err = &BootstrapError{Stage: StageConfig, Err: errors.New("connect with s3cr3t-pwd"), masker: appStarter.masker()}
+	assert.Equal(t, "app bootstrap config: connect with ******", err.Error())
+
+	// the patterns and the secrets are scoped to the starter
+	assert.False(t, secret.Default().IsSecretKey("db.passwd"))
+	assert.Equal(t, "s3cr3t-pwd", secret.Default().Redact("s3cr3t-pwd"))
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/logging"
+	"github.com/go-kratos/kratos/v2/middleware/metadata"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
+	"github.com/go-kratos/kratos/v2/middleware/tracing"
+	"github.com/go-kratos/kratos/v2/middleware/validate"
+	"github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/go-kratos/kratos/v2/transport/http"
+
+	appLog "github.com/liuxiong332/kratos-starter/logger"
+	"github.com/liuxiong332/kratos-starter/metrics"
+)
+
+// ServerTLSConfig is the server TLS config, the client cert is verified if
+// the ClientCAFile is set.
+type ServerTLSConfig struct {
+	CertFile     string `json:"cert_file"`
+	KeyFile      string `json:"key_file"`
+	ClientCAFile string `json:"client_ca_file"`
+}
+
+// ServerConfig is the config of the server.http and server.grpc block.
+type ServerConfig struct {
+	Network    string           `json:"network"`
+	Address    string           `json:"address"`
+	Timeout    Duration         `json:"timeout"`
+	TLS        *ServerTLSConfig `json:"tls"`
+	Middleware []string         `json:"middleware"`
+}
+
+// MiddlewareBuilder builds the server middleware by the starter.
+type MiddlewareBuilder func(s *AppStarter) middleware.Middleware
+
+var (
+	middlewareLock     sync.RWMutex
+	middlewareBuilders = map[string]MiddlewareBuilder{
+		"recovery": func(s *AppStarter) middleware.Middleware { return recovery.Recovery() },
+		"logging":  func(s *AppStarter) middleware.Middleware { return logging.Server(s.requestLogger()) },
+		"metadata": func(s *AppStarter) middleware.Middleware { return metadata.Server() },
+		"validate": func(s *AppStarter) middleware.Middleware { return validate.Validator() },
+		"tracing":  func(s *AppStarter) middleware.Middleware { return tracing.Server() },
+		// the request logger is installed into the context, see logger.FromContext
+		"request_logger": func(s *AppStarter) middleware.Middleware { return appLog.Server(s.Logger) },
+	}
+	defaultMiddleware = []string{"recovery"}
+)
+
+// RegisterMiddleware registers the middleware builder, the middleware can be
+// enabled by the name in the server middleware list.
+func RegisterMiddleware(name string, builder MiddlewareBuilder) {
+	middlewareLock.Lock()
+	defer middlewareLock.Unlock()
+	middlewareBuilders[name] = builder
+}
+
+func (s *AppStarter) serverConfig(key string) (*ServerConfig, error) {
+	serverConfig := &ServerConfig{}
+	if err := s.Config.Value(key).Scan(serverConfig); err != nil && !errors.Is(err, config.ErrNotFound) {
+		return nil, fmt.Errorf("scan %s config: %w", key, err)
+	}
+	return serverConfig, nil
+}
+
+func (s *AppStarter) serverMiddleware(names []string) ([]middleware.Middleware, error) {
+	if names == nil {
+		names = defaultMiddleware
+	}
+	middlewareLock.RLock()
+	defer middlewareLock.RUnlock()
+	var ms []middleware.Middleware
+	for _, name := range names {
+		builder, ok := middlewareBuilders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %s", name)
+		}
+		ms = append(ms, builder(s))
+	}
+	return ms, nil
+}
+
+func loadServerTLSConfig(c *ServerTLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if c.ClientCAFile != "" {
+		ca, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid client ca file %s", c.ClientCAFile)
+		}
+		tlsConf.ClientCAs = pool
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConf, nil
+}
+
+// NewHTTPServer creates the kratos HTTP server from the server.http config,
+// the address defaults to server.port. The server is run by Run and
+// registered to the registry, the /healthz and /readyz handlers of the Health
+// and the /metrics handler if enabled are mounted. The opts override the
+// config.
+func (s *AppStarter) NewHTTPServer(opts ...http.ServerOption) (*http.Server, error) {
+	serverConfig, err := s.serverConfig("server.http")
+	if err != nil {
+		return nil, err
+	}
+	if serverConfig.Address == "" {
+		if port, err := s.Config.Value("server.port").String(); err == nil && port != "" {
+			serverConfig.Address = ":" + port
+		}
+	}
+
+	var serverOpts []http.ServerOption
+	if serverConfig.Network != "" {
+		serverOpts = append(serverOpts, http.Network(serverConfig.Network))
+	}
+	if serverConfig.Address != "" {
+		serverOpts = append(serverOpts, http.Address(serverConfig.Address))
+	}
+	if serverConfig.Timeout != 0 {
+		serverOpts = append(serverOpts, http.Timeout(time.Duration(serverConfig.Timeout)))
+	}
+	if serverConfig.TLS != nil {
+		tlsConf, err := loadServerTLSConfig(serverConfig.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("load server.http tls: %w", err)
+		}
+		serverOpts = append(serverOpts, http.TLSConfig(tlsConf))
+	}
+	ms, err := s.serverMiddleware(serverConfig.Middleware)
+	if err != nil {
+		return nil, err
+	}
+	serverOpts = append(serverOpts, http.Middleware(ms...))
+
+	srv := http.NewServer(append(serverOpts, opts...)...)
+	if s.Health != nil {
+		s.Health.Mount(srv)
+	}
+	if s.Metrics != nil {
+		srv.Handle(metrics.Path, s.Metrics.Handler())
+	}
+	s.addServer(srv)
+	return srv, nil
+}
+
+// NewGRPCServer creates the kratos gRPC server from the server.grpc config.
+// The server is run by Run and registered to the registry. The opts override
+// the config.
+func (s *AppStarter) NewGRPCServer(opts ...grpc.ServerOption) (*grpc.Server, error) {
+	serverConfig, err := s.serverConfig("server.grpc")
+	if err != nil {
+		return nil, err
+	}
+
+	var serverOpts []grpc.ServerOption
+	if serverConfig.Network != "" {
+		serverOpts = append(serverOpts, grpc.Network(serverConfig.Network))
+	}
+	if serverConfig.Address != "" {
+		serverOpts = append(serverOpts, grpc.Address(serverConfig.Address))
+	}
+	if serverConfig.Timeout != 0 {
+		serverOpts = append(serverOpts, grpc.Timeout(time.Duration(serverConfig.Timeout)))
+	}
+	if serverConfig.TLS != nil {
+		tlsConf, err := loadServerTLSConfig(serverConfig.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("load server.grpc tls: %w", err)
+		}
+		serverOpts = append(serverOpts, grpc.TLSConfig(tlsConf))
+	}
+	ms, err := s.serverMiddleware(serverConfig.Middleware)
+	if err != nil {
+		return nil, err
+	}
+	serverOpts = append(serverOpts, grpc.Middleware(ms...))
+
+	srv := grpc.NewServer(append(serverOpts, opts...)...)
+	s.addServer(srv)
+	return srv, nil
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+	"github.com/liuxiong332/kratos-starter/health"
+	"github.com/liuxiong332/kratos-starter/metrics"
+)
+
+func TestDuration(t *testing.T) {
+	var v struct {
+		A Duration `json:"a"`
+		B Duration `json:"b"`
+		C Duration `json:"c"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"a":"1.5s","b":2,"c":"3"}`), &v))
+	assert.Equal(t, Duration(1500*time.Millisecond), v.A)
+	assert.Equal(t, Duration(2*time.Second), v.B)
+	assert.Equal(t, Duration(3*time.Second), v.C)
+}
+
+func TestNewServer(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml": `
+server:
+  http:
+    address: 127.0.0.1:0
+    timeout: 2s
+    middleware: [recovery, logging, metadata]
+  grpc:
+    address: 127.0.0.1:0
+`,
+	})
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: dir},
+		WithLogger(nopLogger),
+	)
+	assert.NoError(t, err)
+
+	httpSrv, err := appStarter.NewHTTPServer()
+	assert.NoError(t, err)
+	endpoint, err := httpSrv.Endpoint()
+	assert.NoError(t, err)
+	assert.Equal(t, "http", endpoint.Scheme)
+
+	// the health handlers are mounted
+	w := httptest.NewRecorder()
+	httpSrv.ServeHTTP(w, httptest.NewRequest("GET", health.ReadinessPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	_, err = appStarter.NewGRPCServer()
+	assert.NoError(t, err)
+	assert.Len(t, appStarter.Servers(), 2)
+}
+
+func TestNewServerUnknownMiddleware(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"application.yaml": "server:\n  http:\n    middleware: [unknown]\n",
+	})
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: dir},
+		WithLogger(nopLogger),
+	)
+	assert.NoError(t, err)
+
+	_, err = appStarter.NewHTTPServer()
+	assert.ErrorContains(t, err, "unknown middleware")
+	assert.Empty(t, appStarter.Servers())
+}
+
+func TestNewServerMetrics(t *testing.T) {
+	t.Cleanup(func() { metrics.Enable(nil) })
+	src := memory.New(map[string]interface{}{"server.http.address": "127.0.0.1:0"})
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithLogger(nopLogger),
+		WithMetrics(metrics.New()),
+		WithConfigSources(src),
+	)
+	assert.NoError(t, err)
+	httpSrv, err := appStarter.NewHTTPServer()
+	assert.NoError(t, err)
+
+	src.Set(map[string]interface{}{"server.http.address": "127.0.0.1:0", "server.name": "test"})
+	assert.Eventually(t, func() bool {
+		w := httptest.NewRecorder()
+		httpSrv.ServeHTTP(w, httptest.NewRequest("GET", metrics.Path, nil))
+		return strings.Contains(w.Body.String(), `kratos_starter_config_reloads_total{result="ok",source="custom"} 1`)
+	}, time.Second, 10*time.Millisecond)
+}
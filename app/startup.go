@@ -0,0 +1,112 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// ComponentStatus is the bootstrap status of the starter component.
+type ComponentStatus string
+
+const (
+	StatusOK ComponentStatus = "ok"
+	// StatusDegraded means the component is up but not as configured, like
+	// vault is not found
+	StatusDegraded ComponentStatus = "degraded"
+	StatusSkipped  ComponentStatus = "skipped"
+	StatusFailed   ComponentStatus = "failed"
+)
+
+// ComponentReport is the bootstrap result of one starter component.
+type ComponentReport struct {
+	Component Stage           `json:"component"`
+	Status    ComponentStatus `json:"status"`
+	// Endpoint is the address or the path used by the component
+	Endpoint string   `json:"endpoint,omitempty"`
+	Duration Duration `json:"duration"`
+	// Reason tells why the component is degraded, skipped or failed
+	Reason string `json:"reason,omitempty"`
+}
+
+// StartupReport is the bootstrap result of the starter components in order.
+type StartupReport struct {
+	Components []ComponentReport `json:"components"`
+	Duration   Duration          `json:"duration"`
+}
+
+// Component returns the report of the component.
+func (r StartupReport) Component(component Stage) (ComponentReport, bool) {
+	for _, c := range r.Components {
+		if c.Component == component {
+			return c, true
+		}
+	}
+	return ComponentReport{}, false
+}
+
+// startupRecorder records the components during NewAppE.
+type startupRecorder struct {
+	start  time.Time
+	report StartupReport
+}
+
+func newStartupRecorder() *startupRecorder {
+	return &startupRecorder{start: time.Now()}
+}
+
+// add records the component started at start, the earlier record of the
+// component is replaced, like the logger rebuilt by the log config.
+func (r *startupRecorder) add(component Stage, start time.Time, status ComponentStatus, endpoint string, reason string) {
+	c := ComponentReport{
+		Component: component,
+		Status:    status,
+		Endpoint:  endpoint,
+		Duration:  Duration(time.Since(start)),
+		Reason:    reason,
+	}
+	for i := range r.report.Components {
+		if r.report.Components[i].Component == component {
+			r.report.Components[i] = c
+			return
+		}
+	}
+	r.report.Components = append(r.report.Components, c)
+}
+
+// skip records the skipped component.
+func (r *startupRecorder) skip(component Stage, reason string) {
+	r.add(component, time.Now(), StatusSkipped, "", reason)
+}
+
+// fail records the failed component of the bootstrap error.
+func (r *startupRecorder) fail(start time.Time, endpoint string, err error) {
+	stage := StageBootstrap
+	var bootstrapErr *BootstrapError
+	if errors.As(err, &bootstrapErr) {
+		stage = bootstrapErr.Stage
+	}
+	r.add(stage, start, StatusFailed, endpoint, err.Error())
+}
+
+// finish returns the report and logs it as one event, the warn level is used
+// if any component is not ok or skipped.
+func (r *startupRecorder) finish(logger log.Logger) StartupReport {
+	r.report.Duration = Duration(time.Since(r.start))
+	report := r.report
+
+	level := log.LevelInfo
+	for _, c := range report.Components {
+		if c.Status == StatusDegraded || c.Status == StatusFailed {
+			level = log.LevelWarn
+		}
+	}
+	_ = logger.Log(level, log.DefaultMessageKey, "Startup report", "duration", report.Duration, "components", report.Components)
+	return report
+}
+
+// StartupReport returns the bootstrap result of the starter components.
+func (s *AppStarter) StartupReport() StartupReport {
+	return s.startupReport
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+)
+
+func TestStartupReport(t *testing.T) {
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithLogger(nopLogger),
+	)
+	assert.NoError(t, err)
+
+	report := appStarter.StartupReport()
+	var components []Stage
+	for _, c := range report.Components {
+		components = append(components, c.Component)
+	}
+	assert.Equal(t, []Stage{StageLogger, StageConsul, StageRegistry, StageVault, StageConfig, StageTracing, StageAudit}, components)
+
+	consul, _ := report.Component(StageConsul)
+	assert.Equal(t, StatusSkipped, consul.Status)
+	assert.Equal(t, "local mode", consul.Reason)
+	registry, _ := report.Component(StageRegistry)
+	assert.Equal(t, StatusOK, registry.Status)
+	assert.Equal(t, "memory", registry.Endpoint)
+	cfg, _ := report.Component(StageConfig)
+	assert.Equal(t, StatusOK, cfg.Status)
+	assert.Equal(t, "env", cfg.Endpoint)
+}
+
+func TestStartupReportFailed(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+	_, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: configPath},
+		WithLogger(zapLog.NewLogger(zap.New(core))),
+	)
+	assert.True(t, IsStage(err, StageConfig))
+
+	entries := logs.FilterMessage("Startup report").All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+		components := entries[0].ContextMap()["components"].([]ComponentReport)
+		last := components[len(components)-1]
+		assert.Equal(t, StageConfig, last.Component)
+		assert.Equal(t, StatusFailed, last.Status)
+		assert.Equal(t, configPath, last.Endpoint)
+	}
+}
+
+func TestStartupReportLogConfig(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithConfigSources(memory.New(map[string]interface{}{
+			"log.outputs": []interface{}{map[string]interface{}{"path": logPath}},
+		})),
+	)
+	assert.NoError(t, err)
+	defer appStarter.Close(context.Background())
+
+	logger, _ := appStarter.StartupReport().Component(StageLogger)
+	assert.Equal(t, StatusOK, logger.Status)
+	assert.Equal(t, "file:"+logPath, logger.Endpoint)
+
+	_, err = NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithConfigSources(memory.New(map[string]interface{}{"log.level": "unknown"})),
+	)
+	assert.True(t, IsStage(err, StageLogger))
+}
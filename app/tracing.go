@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	appLog "github.com/liuxiong332/kratos-starter/logger"
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+	"github.com/liuxiong332/kratos-starter/tracing"
+)
+
+// newTracerProvider creates the tracer provider from the tracing config, the
+// service name defaults to the app name. The provider is nil if no tracing
+// config and the tracing is not enabled by WithTracing.
+func newTracerProvider(ctx context.Context, appName string, cfg config.Config, o *options) (*sdktrace.TracerProvider, string, error) {
+	if cfg.Value("tracing").Load() == nil && !o.tracing {
+		return nil, "", nil
+	}
+	tracingConfig, err := BindConfig[tracing.Config](cfg, "tracing")
+	if err != nil {
+		return nil, "", err
+	}
+	if tracingConfig.ServiceName == "" {
+		tracingConfig.ServiceName = appName
+	}
+	provider, err := tracing.NewTracerProvider(ctx, tracingConfig, o.tracingOpts...)
+	if err != nil {
+		return nil, tracingConfig.Endpoint, err
+	}
+	return provider, tracingConfig.Endpoint, nil
+}
+
+// requestLogger returns the logger of the requests, the trace_id and span_id
+// fields are added if the tracing is enabled.
+func (s *AppStarter) requestLogger() log.Logger {
+	if s.TracerProvider == nil {
+		return s.Logger
+	}
+	return tracing.WithTraceFields(s.Logger)
+}
+
+// withTraceValuers returns the logger which adds the trace_id and span_id of
+// the span in the ctx of Logger.WithContext, the fields are omitted without the
+// span.
+func withTraceValuers(logger *zapLog.Logger) *zapLog.Logger {
+	return logger.With(appLog.FieldTraceID, tracing.TraceIDValuer(), appLog.FieldSpanID, tracing.SpanIDValuer())
+}
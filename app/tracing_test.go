@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+	"github.com/liuxiong332/kratos-starter/tracing"
+)
+
+func TestNewAppTracing(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithLogger(nopLogger),
+		WithTracing(tracing.WithExporter(exporter)),
+		WithConfigSources(memory.New(map[string]interface{}{"tracing.sampler_ratio": 1})),
+	)
+	assert.NoError(t, err)
+	assert.NotNil(t, appStarter.TracerProvider)
+	report, _ := appStarter.StartupReport().Component(StageTracing)
+	assert.Equal(t, StatusOK, report.Status)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "hello")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "hello", spans[0].Name)
+		assert.Contains(t, spans[0].Resource.Attributes(), semconv.ServiceName("test"))
+	}
+	assert.NoError(t, appStarter.Close(context.Background()))
+}
+
+func TestNewAppTracingLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal, ConfigPath: t.TempDir()},
+		WithLogger(zapLog.NewLogger(zap.New(core))),
+		WithTracing(tracing.WithExporter(tracing.NewInMemoryExporter())),
+	)
+	assert.NoError(t, err)
+	logs.TakeAll()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "hello")
+	log.NewHelper(appStarter.Logger.WithContext(ctx)).Info("in span")
+	span.End()
+	log.NewHelper(appStarter.Logger).Info("no span")
+
+	entries := logs.TakeAll()
+	if assert.Len(t, entries, 2) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, span.SpanContext().TraceID().String(), fields["trace_id"])
+		assert.Equal(t, span.SpanContext().SpanID().String(), fields["span_id"])
+		assert.NotContains(t, entries[1].ContextMap(), "trace_id")
+	}
+	assert.NoError(t, appStarter.Close(context.Background()))
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/registry"
+	vaultApi "github.com/hashicorp/vault/api"
+
+	vaultConfig "github.com/liuxiong332/kratos-starter/config/vault"
+	"github.com/liuxiong332/kratos-starter/logger/audit"
+)
+
+const kubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// vaultAddress returns the configured vault address, or discovers the vault
+// service by the discovery. The discovery error fails the vault stage, the
+// registry itself is already created.
+func vaultAddress(ctx context.Context, discovery registry.Discovery, bootstrapConfig *BootstrapConfig) (string, error) {
+	vaultAddr := bootstrapConfig.VaultAddress
+	if vaultAddr == "" && discovery != nil {
+		lookupCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+		defer cancel()
+		addr, err := lookupInstance(lookupCtx, discovery, "vault")
+		if err != nil {
+			return "", newBootstrapError(StageVault, fmt.Errorf("discover vault: %w", err))
+		}
+		vaultAddr = addr
+	}
+	if vaultAddr != "" && !strings.HasPrefix(vaultAddr, "http://") && !strings.HasPrefix(vaultAddr, "https://") {
+		vaultAddr = "http://" + vaultAddr
+	}
+	return vaultAddr, nil
+}
+
+// vaultLogin sets the client token by the auth method of the bootstrap config.
+func vaultLogin(client *vaultApi.Client, bootstrapConfig *BootstrapConfig) error {
+	var path string
+	var data map[string]interface{}
+
+	switch bootstrapConfig.VaultAuthMethod {
+	case "", VaultAuthToken:
+		client.SetToken(bootstrapConfig.VaultToken)
+		return nil
+	case VaultAuthAppRole:
+		path = "auth/approle/login"
+		data = map[string]interface{}{"role_id": bootstrapConfig.VaultRole, "secret_id": bootstrapConfig.VaultSecretID}
+	case VaultAuthKubernetes:
+		jwt, err := os.ReadFile(kubernetesTokenPath)
+		if err != nil {
+			return err
+		}
+		path = "auth/kubernetes/login"
+		data = map[string]interface{}{"role": bootstrapConfig.VaultRole, "jwt": strings.TrimSpace(string(jwt))}
+	default:
+		return fmt.Errorf("unknown vault auth method %s", bootstrapConfig.VaultAuthMethod)
+	}
+
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Auth == nil {
+		return errors.New("vault login returns no auth")
+	}
+	client.SetToken(secret.Auth.ClientToken)
+	return nil
+}
+
+// newVaultConfig returns the vault source and the vault client, the source is
+// nil if no vault address is found. The client is returned if created even if
+// the error is returned. The ctx bounds the discovery, the watchCtx stops the
+// watchers of the source.
+func newVaultConfig(ctx context.Context, watchCtx context.Context, discovery registry.Discovery, vaultPath string, bootstrapConfig *BootstrapConfig) (config.Source, *vaultApi.Client, error) {
+	// 初始化 vault config
+	vaultAddr, err := vaultAddress(ctx, discovery, bootstrapConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if vaultAddr != "" {
+		vaultClient, err := vaultApi.NewClient(&vaultApi.Config{
+			Address: vaultAddr,
+		})
+		if err != nil {
+			return nil, nil, newBootstrapError(StageVault, err)
+		}
+		if err := vaultLogin(vaultClient, bootstrapConfig); err != nil {
+			return nil, vaultClient, newBootstrapError(StageVault, fmt.Errorf("vault login: %w", err))
+		}
+
+		vaultSrc, err := vaultConfig.New(vaultClient, vaultConfig.WithContext(watchCtx), vaultConfig.WithPath(vaultPath))
+		if err != nil {
+			return nil, vaultClient, newBootstrapError(StageVault, fmt.Errorf("new vault config: %w", err))
+		}
+		return &auditedSource{Source: vaultSrc, path: vaultPath}, vaultClient, nil
+	}
+	return nil, nil, nil
+}
+
+// auditedSource records the vault reads of the watcher by the audit log, the
+// startup read is recorded when the audit log is created.
+type auditedSource struct {
+	config.Source
+	path string
+}
+
+func (s *auditedSource) Watch() (config.Watcher, error) {
+	w, err := s.Source.Watch()
+	if err != nil {
+		return nil, err
+	}
+	return &auditedWatcher{Watcher: w, path: s.path}, nil
+}
+
+// auditedWatcher records the changed secret and the failed read, the values
+// are not recorded.
+type auditedWatcher struct {
+	config.Watcher
+	path string
+}
+
+func (w *auditedWatcher) Next() ([]*config.KeyValue, error) {
+	kvs, err := w.Watcher.Next()
+	if errors.Is(err, context.Canceled) {
+		return kvs, err
+	}
+	event := audit.Event{
+		Type:    audit.EventVaultRead,
+		Target:  w.path,
+		Details: map[string]string{"keys": strconv.Itoa(len(kvs))},
+	}
+	if err != nil {
+		event.Outcome = audit.OutcomeFailure
+		event.Details["error"] = err.Error()
+	}
+	_ = audit.Default().Record(event)
+	return kvs, err
+}
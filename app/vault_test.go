@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuxiong332/kratos-starter/logger"
+	"github.com/liuxiong332/kratos-starter/logger/audit"
+	memoryRegistry "github.com/liuxiong332/kratos-starter/registry/memory"
+)
+
+// seqWatcher returns the results in order.
+type seqWatcher struct {
+	results []error
+}
+
+func (w *seqWatcher) Next() ([]*config.KeyValue, error) {
+	err := w.results[0]
+	w.results = w.results[1:]
+	if err != nil {
+		return nil, err
+	}
+	return []*config.KeyValue{{Key: "password", Value: []byte("s3cr3t")}}, nil
+}
+
+func (w *seqWatcher) Stop() error {
+	return nil
+}
+
+func TestAuditedWatcher(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	auditLogger, err := audit.New(&audit.Config{Outputs: []logger.OutputConfig{{Type: logger.OutputFile, Path: auditPath}}})
+	assert.NoError(t, err)
+	audit.Enable(auditLogger)
+	defer audit.Enable(nil)
+
+	// the change and the failed read are recorded, the stop is not
+	w := &auditedWatcher{Watcher: &seqWatcher{results: []error{nil, errors.New("permission denied"), context.Canceled}}, path: "secret/test"}
+	_, err = w.Next()
+	assert.NoError(t, err)
+	_, err = w.Next()
+	assert.Error(t, err)
+	_, err = w.Next()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, auditLogger.Close())
+
+	data, err := os.ReadFile(auditPath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+
+	f, err := os.Open(auditPath)
+	assert.NoError(t, err)
+	defer f.Close()
+	last, err := audit.Verify(f)
+	assert.NoError(t, err)
+	if assert.NotNil(t, last) {
+		assert.Equal(t, uint64(2), last.Seq)
+		assert.Equal(t, audit.EventVaultRead, last.Event)
+		assert.Equal(t, "secret/test", last.Target)
+		assert.Equal(t, audit.OutcomeFailure, last.Outcome)
+	}
+}
+
+// failDiscovery fails the watch of every service.
+type failDiscovery struct {
+	registry.Discovery
+}
+
+func (failDiscovery) Watch(ctx context.Context, serviceName string) (registry.Watcher, error) {
+	return nil, errors.New("consul unavailable")
+}
+
+func TestVaultAddressStage(t *testing.T) {
+	_, err := vaultAddress(context.Background(), failDiscovery{Discovery: memoryRegistry.New()}, &BootstrapConfig{})
+	assert.True(t, IsStage(err, StageVault))
+
+	// the registry stays ok in the startup report, the vault stage fails
+	recorder := newStartupRecorder()
+	recorder.add(StageRegistry, time.Now(), StatusOK, "consul", "")
+	recorder.fail(time.Now(), "", err)
+	registryReport, _ := recorder.report.Component(StageRegistry)
+	assert.Equal(t, StatusOK, registryReport.Status)
+	vaultReport, _ := recorder.report.Component(StageVault)
+	assert.Equal(t, StatusFailed, vaultReport.Status)
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/log"
+
+	"github.com/liuxiong332/kratos-starter/logger/audit"
+	"github.com/liuxiong332/kratos-starter/secret"
+)
+
+// observe calls fn whenever the config of the key changes. The kratos config
+// keeps only one observer per key, so the observers of the key are dispatched
+// by the starter. The key not found is watched once it is created by the
+// config change, see configApplied.
+func (s *AppStarter) observe(key string, fn func()) error {
+	s.observersLock.Lock()
+	defer s.observersLock.Unlock()
+	if s.observers == nil {
+		s.observers = make(map[string][]func())
+	}
+	if _, ok := s.observers[key]; !ok {
+		err := s.Config.Watch(key, func(string, config.Value) { s.notify(key) })
+		if errors.Is(err, config.ErrNotFound) {
+			if s.pendingKeys == nil {
+				s.pendingKeys = make(map[string]struct{})
+			}
+			s.pendingKeys[key] = struct{}{}
+		} else if err != nil {
+			return fmt.Errorf("watch config %s: %w", key, err)
+		}
+	}
+	s.observers[key] = append(s.observers[key], fn)
+	return nil
+}
+
+// configApplied watches the pending keys created by the config change, and
+// notifies their observers. It is called after the change of the config
+// source is applied.
+func (s *AppStarter) configApplied() {
+	s.observersLock.Lock()
+	var created []string
+	for key := range s.pendingKeys {
+		if s.Config.Value(key).Load() == nil {
+			continue
+		}
+		key := key
+		if err := s.Config.Watch(key, func(string, config.Value) { s.notify(key) }); err != nil {
+			continue
+		}
+		delete(s.pendingKeys, key)
+		created = append(created, key)
+	}
+	s.observersLock.Unlock()
+	for _, key := range created {
+		s.notify(key)
+	}
+}
+
+func (s *AppStarter) notify(key string) {
+	s.observersLock.Lock()
+	observers := append([]func(){}, s.observers[key]...)
+	s.observersLock.Unlock()
+	for _, fn := range observers {
+		fn()
+	}
+}
+
+// Watch binds the config of the key into T like Bind, then calls fn with the
+// old and new value whenever the config changes. The invalid update is
+// rejected and logged, fn is not called.
+func Watch[T any](s *AppStarter, key string, fn func(old, new T)) error {
+	current, err := Bind[T](s, key)
+	if err != nil {
+		return err
+	}
+	return watchBound(s, key, current, fn)
+}
+
+func watchBound[T any](s *AppStarter, key string, current *T, fn func(old, new T)) error {
+	var lock sync.Mutex
+	return s.observe(key, func() {
+		logHelper := log.NewHelper(s.Logger)
+		next, err := Bind[T](s, key)
+		if err != nil {
+			logHelper.Errorf("Reject config %s update: %v", key, err)
+			return
+		}
+
+		lock.Lock()
+		old := current
+		current = next
+		lock.Unlock()
+
+		diff := configDiff(key, old, next, s.masker())
+		if len(diff) == 0 {
+			return
+		}
+		logHelper.Infof("Config %s changed: %s", key, strings.Join(diff, ", "))
+		// the diff is masked, so it is safe to be recorded
+		if err := audit.Default().Record(audit.Event{
+			Type:    audit.EventConfigChanged,
+			Target:  key,
+			Details: map[string]string{"diff": strings.Join(diff, ", ")},
+		}); err != nil {
+			logHelper.Errorf("Record config %s change: %v", key, err)
+		}
+		fn(*old, *next)
+	})
+}
+
+// Live is the config value which is re-bound whenever the config changes.
+type Live[T any] struct {
+	value atomic.Pointer[T]
+}
+
+// NewLive binds the config of the key into the live value, see Watch.
+func NewLive[T any](s *AppStarter, key string) (*Live[T], error) {
+	current, err := Bind[T](s, key)
+	if err != nil {
+		return nil, err
+	}
+	l := &Live[T]{}
+	l.value.Store(current)
+	err = watchBound(s, key, current, func(_, next T) {
+		l.value.Store(&next)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Load returns the current config value, it must not be modified.
+func (l *Live[T]) Load() *T {
+	return l.value.Load()
+}
+
+// flattenConfig flattens the value to the dot-separated keys and the values
+// by the json encoding.
+func flattenConfig(v interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	data, err := json.Marshal(v)
+	if err != nil {
+		return flat
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return flat
+	}
+	flattenValue("", decoded, flat)
+	return flat
+}
+
+func flattenValue(prefix string, v interface{}, flat map[string]interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		flat[prefix] = v
+		return
+	}
+	for key, value := range m {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		flattenValue(key, value, flat)
+	}
+}
+
+// configDiff returns the changed keys of the values, like "port: 80 -> 8080".
+// The values of the secret keys are masked.
+func configDiff(key string, old, new interface{}, masker *secret.Masker) []string {
+	oldFlat, newFlat := flattenConfig(old), flattenConfig(new)
+	keys := make(map[string]struct{})
+	for k := range oldFlat {
+		keys[k] = struct{}{}
+	}
+	for k := range newFlat {
+		keys[k] = struct{}{}
+	}
+
+	var diff []string
+	for k := range keys {
+		oldValue, oldOk := oldFlat[k]
+		newValue, newOk := newFlat[k]
+		if oldOk && newOk && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		fullKey := k
+		if key != "" {
+			fullKey = key + "." + k
+		}
+		if masker.IsSecretKey(fullKey) {
+			diff = append(diff, fmt.Sprintf("%s: %s", k, secret.Mask))
+			continue
+		}
+		diff = append(diff, fmt.Sprintf("%s: %s -> %s", k, formatDiffValue(oldValue, oldOk), formatDiffValue(newValue, newOk)))
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+func formatDiffValue(v interface{}, ok bool) string {
+	if !ok {
+		return "<none>"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+	"github.com/liuxiong332/kratos-starter/logger/audit"
+	"github.com/liuxiong332/kratos-starter/secret"
+)
+
+type testServerConfig struct {
+	Port int    `json:"port" validate:"gte=1,lte=65535"`
+	Name string `json:"name"`
+}
+
+func TestWatch(t *testing.T) {
+	src := memory.New(map[string]interface{}{"server.port": 8000, "server.name": "test"})
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal},
+		WithLogger(nopLogger),
+		WithConfigSources(src),
+	)
+	assert.NoError(t, err)
+	defer appStarter.Close(context.Background())
+
+	type change struct{ old, new testServerConfig }
+	changes := make(chan change, 1)
+	assert.NoError(t, Watch(appStarter, "server", func(old, new testServerConfig) {
+		changes <- change{old, new}
+	}))
+	live, err := NewLive[testServerConfig](appStarter, "server")
+	assert.NoError(t, err)
+	assert.Equal(t, 8000, live.Load().Port)
+
+	src.Set(map[string]interface{}{"server.port": 9000})
+	select {
+	case c := <-changes:
+		assert.Equal(t, 8000, c.old.Port)
+		assert.Equal(t, 9000, c.new.Port)
+	case <-time.After(time.Second * 5):
+		t.Fatal("config change is not watched")
+	}
+	assert.Equal(t, 9000, live.Load().Port)
+
+	// the invalid update is rejected
+	src.Set(map[string]interface{}{"server.port": 70000})
+	select {
+	case <-changes:
+		t.Fatal("invalid config change is watched")
+	case <-time.After(time.Millisecond * 200):
+	}
+	assert.Equal(t, 9000, live.Load().Port)
+}
+
+func TestWatchAudit(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	src := memory.New(map[string]interface{}{
+		"server.port":   8000,
+		"audit.outputs": []interface{}{map[string]interface{}{"type": "file", "path": auditPath}},
+	})
+	appStarter, err := NewAppE(context.Background(), "test", &BootstrapConfig{Mode: ModeLocal},
+		WithLogger(nopLogger),
+		WithConfigSources(src),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, appStarter.Audit, audit.Default())
+
+	changes := make(chan struct{}, 1)
+	assert.NoError(t, Watch(appStarter, "server", func(old, new testServerConfig) {
+		changes <- struct{}{}
+	}))
+	src.Set(map[string]interface{}{"server.port": 9000})
+	select {
+	case <-changes:
+	case <-time.After(time.Second * 5):
+		t.Fatal("config change is not watched")
+	}
+	assert.NoError(t, appStarter.Close(context.Background()))
+	assert.Nil(t, audit.Default())
+
+	f, err := os.Open(auditPath)
+	assert.NoError(t, err)
+	defer f.Close()
+	last, err := audit.Verify(f)
+	assert.NoError(t, err)
+	if assert.NotNil(t, last) {
+		assert.Equal(t, audit.EventConfigChanged, last.Event)
+		assert.Equal(t, "test", last.Actor)
+		assert.Equal(t, "server", last.Target)
+		assert.Equal(t, "port: 8000 -> 9000", last.Details["diff"])
+	}
+}
+
+func TestConfigDiff(t *testing.T) {
+	diff := configDiff("server",
+		testServerConfig{Port: 80, Name: "a"},
+		testServerConfig{Port: 8080, Name: "a"},
+		secret.NewMasker("*name*"),
+	)
+	assert.Equal(t, []string{"port: 80 -> 8080"}, diff)
+
+	diff = configDiff("server",
+		testServerConfig{Port: 80, Name: "a"},
+		testServerConfig{Port: 80, Name: "b"},
+		secret.NewMasker("*name*"),
+	)
+	assert.Equal(t, []string{"name: ******"}, diff)
+}
@@ -0,0 +1,78 @@
+// Command audit-verify validates the hash chain of the audit log files.
+//
+//	audit-verify [-key KEY] [-from-seq SEQ -prev-hash HASH] audit.log.1 audit.log
+//
+// The files are verified in order and each file is chained from the previous
+// one, so the rotated files are passed from the oldest. The first file must
+// start from seq 1, unless -from-seq and -prev-hash give the last record
+// before it, like the seq and hash of the last record of the removed files.
+// The HMAC key can be set by the AUDIT_HMAC_KEY env too.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/liuxiong332/kratos-starter/logger/audit"
+)
+
+func main() {
+	key := flag.String("key", os.Getenv("AUDIT_HMAC_KEY"), "the HMAC key of the audit log")
+	fromSeq := flag.Uint64("from-seq", 0, "the seq of the record before the first file")
+	prevHash := flag.String("prev-hash", "", "the hash of the record before the first file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-key KEY] [-from-seq SEQ -prev-hash HASH] FILE...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if (*fromSeq == 0) != (*prevHash == "") {
+		fmt.Fprintln(os.Stderr, "-from-seq and -prev-hash must be set together")
+		os.Exit(2)
+	}
+
+	var from *audit.Record
+	if *fromSeq > 0 {
+		from = &audit.Record{Seq: *fromSeq, Hash: *prevHash}
+	}
+	if err := verify(*key, from, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "FAIL:", err)
+		os.Exit(1)
+	}
+}
+
+// verify verifies the files chained from the record, from the first record if
+// nil.
+func verify(key string, last *audit.Record, files []string) error {
+	for _, file := range files {
+		opts := []audit.VerifyOption{audit.WithKey(key)}
+		if last != nil {
+			opts = append(opts, audit.WithContinuation(last.Seq, last.Hash))
+		}
+		record, err := verifyFile(file, opts...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if record == nil {
+			fmt.Printf("%s: OK, no records\n", file)
+			continue
+		}
+		fmt.Printf("%s: OK, last seq %d\n", file, record.Seq)
+		last = record
+	}
+	return nil
+}
+
+func verifyFile(file string, opts ...audit.VerifyOption) (*audit.Record, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return audit.Verify(f, opts...)
+}
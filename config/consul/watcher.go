@@ -1,6 +1,8 @@
 package consul
 
 import (
+	"context"
+
 	"github.com/go-kratos/kratos/v2/config"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/api/watch"
@@ -8,6 +10,7 @@ import (
 
 type watcher struct {
 	source    *source
+	plan      *watch.Plan
 	ch        chan interface{}
 	closeChan chan struct{}
 }
@@ -22,7 +25,10 @@ func (w *watcher) handle(idx uint64, data interface{}) {
 		return
 	}
 
-	w.ch <- struct{}{}
+	select {
+	case w.ch <- struct{}{}:
+	case <-w.closeChan:
+	}
 }
 
 func newWatcher(s *source) (*watcher, error) {
@@ -38,6 +44,7 @@ func newWatcher(s *source) (*watcher, error) {
 	}
 
 	wp.Handler = w.handle
+	w.plan = wp
 
 	// wp.Run is a blocking call and will prevent newWatcher from returning
 	go func() {
@@ -54,15 +61,16 @@ func (w *watcher) Next() ([]*config.KeyValue, error) {
 	select {
 	case _, ok := <-w.ch:
 		if !ok {
-			return nil, nil
+			return nil, context.Canceled
 		}
 		return w.source.Load()
 	case <-w.closeChan:
-		return nil, nil
+		return nil, context.Canceled
 	}
 }
 
 func (w *watcher) Stop() error {
 	close(w.closeChan)
+	w.plan.Stop()
 	return nil
 }
@@ -1,70 +1,109 @@
 package memory
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"reflect"
-	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/go-kratos/kratos/v2/config"
 )
 
-type source struct {
-	values map[string]interface{}
+var _ config.Source = (*Source)(nil)
+
+// Source is the in-memory config source, the watchers are notified when the
+// values are updated by Set.
+type Source struct {
+	values   map[string]interface{}
+	watchers map[*watcher]struct{}
+	lock     sync.RWMutex
 }
 
-func New(values map[string]interface{}) config.Source {
-	return &source{values: values}
+func New(values map[string]interface{}) *Source {
+	copied := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return &Source{values: copied, watchers: make(map[*watcher]struct{})}
+}
+
+// Set updates the values and notifies the watchers.
+func (s *Source) Set(values map[string]interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key, value := range values {
+		s.values[key] = value
+	}
+	for w := range s.watchers {
+		select {
+		case w.event <- struct{}{}:
+		default:
+		}
+	}
 }
 
-func (s *source) Load() ([]*config.KeyValue, error) {
+// Load returns one json formatted kv per key, the dot-separated key is
+// expanded to the nested map and the value keeps its type.
+func (s *Source) Load() ([]*config.KeyValue, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	kvs := make([]*config.KeyValue, 0)
 	for key, value := range s.values {
-		var result string
-
-		switch reflect.TypeOf(value).Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			result = fmt.Sprintf("%d", value)
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			result = fmt.Sprintf("%d", value)
-		case reflect.String:
-			result = value.(string)
-		case reflect.Bool:
-			result = strconv.FormatBool(value.(bool))
-		case reflect.Float32, reflect.Float64:
-			result = fmt.Sprintf("%f", value)
+		var nested interface{} = value
+		keys := strings.Split(key, ".")
+		for i := len(keys) - 1; i >= 0; i-- {
+			nested = map[string]interface{}{keys[i]: nested}
+		}
+		data, err := json.Marshal(nested)
+		if err != nil {
+			return nil, fmt.Errorf("marshal memory config %s: %w", key, err)
 		}
 		kvs = append(kvs, &config.KeyValue{
-			Key:   key,
-			Value: []byte(result),
+			Key:    key,
+			Value:  data,
+			Format: "json",
 		})
 	}
 	return kvs, nil
 }
 
-func (s *source) Watch() (config.Watcher, error) {
+func (s *Source) Watch() (config.Watcher, error) {
 	return newWatcher(s)
 }
 
 type watcher struct {
-	source    *source
+	source    *Source
+	event     chan struct{}
 	closeChan chan struct{}
 }
 
-func newWatcher(s *source) (*watcher, error) {
+func newWatcher(s *Source) (*watcher, error) {
 	w := &watcher{
 		source:    s,
+		event:     make(chan struct{}, 1),
 		closeChan: make(chan struct{}),
 	}
+	s.lock.Lock()
+	s.watchers[w] = struct{}{}
+	s.lock.Unlock()
 
 	return w, nil
 }
 
 func (w *watcher) Next() ([]*config.KeyValue, error) {
-	<-w.closeChan
-	return nil, nil
+	select {
+	case <-w.event:
+		return w.source.Load()
+	case <-w.closeChan:
+		return nil, context.Canceled
+	}
 }
 
 func (w *watcher) Stop() error {
+	w.source.lock.Lock()
+	delete(w.source.watchers, w)
+	w.source.lock.Unlock()
 	close(w.closeChan)
 	return nil
 }
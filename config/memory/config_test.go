@@ -3,18 +3,48 @@ package memory
 import (
 	"testing"
 
+	"github.com/go-kratos/kratos/v2/config"
+
 	"github.com/stretchr/testify/assert"
 )
 
 func TestConfig(t *testing.T) {
-	src := New(map[string]interface{}{"int": 1, "int8": int8(1), "uint": uint(1), "float": 1.2, "str": "string", "bool": true})
+	src := New(map[string]interface{}{"int": 1, "int8": int8(1), "uint": uint(1), "float": 1.2, "str": "string", "bool": true, "a.b": 1})
 	_, err := src.Load()
 	assert.NoError(t, err)
 
-	// assert.Equal(t, kvs[0].Value, []byte("1"))
-	// assert.Equal(t, kvs[1].Value, []byte("1"))
-	// assert.Equal(t, kvs[2].Value, []byte("1"))
-	// assert.Equal(t, kvs[3].Value, []byte("1.200000"))
-	// assert.Equal(t, kvs[4].Value, []byte("string"))
-	// assert.Equal(t, kvs[5].Value, []byte("true"))
+	cfg := config.New(config.WithSource(src))
+	assert.NoError(t, cfg.Load())
+
+	var values struct {
+		Int   int     `json:"int"`
+		Float float64 `json:"float"`
+		Str   string  `json:"str"`
+		Bool  bool    `json:"bool"`
+		A     struct {
+			B int `json:"b"`
+		} `json:"a"`
+	}
+	assert.NoError(t, cfg.Scan(&values))
+	assert.Equal(t, 1, values.Int)
+	assert.Equal(t, 1.2, values.Float)
+	assert.Equal(t, "string", values.Str)
+	assert.True(t, values.Bool)
+	assert.Equal(t, 1, values.A.B)
+}
+
+func TestConfigSet(t *testing.T) {
+	src := New(map[string]interface{}{"str": "string"})
+	w, err := src.Watch()
+	assert.NoError(t, err)
+
+	src.Set(map[string]interface{}{"str": "updated"})
+	kvs, err := w.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(kvs))
+	assert.Equal(t, []byte(`{"str":"updated"}`), kvs[0].Value)
+
+	assert.NoError(t, w.Stop())
+	_, err = w.Next()
+	assert.Error(t, err)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/config"
 	"github.com/hashicorp/vault/api"
@@ -13,8 +14,9 @@ import (
 type Option func(o *options)
 
 type options struct {
-	ctx  context.Context
-	path string
+	ctx          context.Context
+	path         string
+	pollInterval time.Duration
 }
 
 //  WithContext with registry context.
@@ -31,6 +33,13 @@ func WithPath(p string) Option {
 	})
 }
 
+// WithPollInterval is the interval to poll the secret changes, zero disables the polling.
+func WithPollInterval(d time.Duration) Option {
+	return Option(func(o *options) {
+		o.pollInterval = d
+	})
+}
+
 type source struct {
 	client  *api.Client
 	options *options
@@ -38,8 +47,9 @@ type source struct {
 
 func New(client *api.Client, opts ...Option) (config.Source, error) {
 	options := &options{
-		ctx:  context.Background(),
-		path: "",
+		ctx:          context.Background(),
+		path:         "",
+		pollInterval: time.Minute,
 	}
 
 	for _, opt := range opts {
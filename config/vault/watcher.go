@@ -1,11 +1,17 @@
 package vault
 
 import (
+	"bytes"
+	"context"
+	"time"
+
 	"github.com/go-kratos/kratos/v2/config"
 )
 
+// watcher polls the vault secret, the kvs are returned when the secret changed.
 type watcher struct {
 	source    *source
+	last      map[string][]byte
 	closeChan chan struct{}
 }
 
@@ -14,13 +20,62 @@ func newWatcher(s *source) (*watcher, error) {
 		source:    s,
 		closeChan: make(chan struct{}),
 	}
+	if kvs, err := s.Load(); err == nil {
+		w.last = kvMap(kvs)
+	}
 
 	return w, nil
 }
 
+func kvMap(kvs []*config.KeyValue) map[string][]byte {
+	m := make(map[string][]byte, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func (w *watcher) changed(kvs map[string][]byte) bool {
+	if len(kvs) != len(w.last) {
+		return true
+	}
+	for key, value := range kvs {
+		if last, ok := w.last[key]; !ok || !bytes.Equal(last, value) {
+			return true
+		}
+	}
+	return false
+}
+
 func (w *watcher) Next() ([]*config.KeyValue, error) {
-	<-w.closeChan
-	return nil, nil
+	if w.source.options.pollInterval <= 0 {
+		select {
+		case <-w.closeChan:
+		case <-w.source.options.ctx.Done():
+		}
+		return nil, context.Canceled
+	}
+
+	ticker := time.NewTicker(w.source.options.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.closeChan:
+			return nil, context.Canceled
+		case <-w.source.options.ctx.Done():
+			return nil, context.Canceled
+		}
+		// only the changed secret is returned, not every poll
+		kvs, err := w.source.Load()
+		if err != nil {
+			return nil, err
+		}
+		if m := kvMap(kvs); w.changed(m) {
+			w.last = m
+			return kvs, nil
+		}
+	}
 }
 
 func (w *watcher) Stop() error {
@@ -5,8 +5,7 @@ import (
 	"fmt"
 
 	"github.com/liuxiong332/kratos-starter/app"
-
-	"github.com/go-kratos/kratos/v2"
+	"github.com/liuxiong332/kratos-starter/secret"
 )
 
 func main() {
@@ -16,26 +15,17 @@ func main() {
 
 	err := appStarter.Config.Scan(&kvs)
 	if err != nil {
-		fmt.Errorf("Get config error, %v", err)
+		fmt.Printf("Get config error, %v\n", err)
 	}
 	if configStr, err := json.MarshalIndent(kvs, "", "  "); err == nil {
-		fmt.Printf("Get config: %s", configStr)
+		fmt.Printf("Get config: %s", secret.Default().Redact(string(configStr)))
 	}
 
 	if serverPort, err := appStarter.Config.Value("server.port").String(); err == nil {
 		fmt.Printf("Get server port: %s\n", serverPort)
 	}
 
-	app := kratos.New(
-		kratos.Name("ai-studio"),
-		kratos.Version(""),
-		kratos.Metadata(map[string]string{}),
-		kratos.Logger(appStarter.Logger),
-		kratos.Registrar(appStarter.Registry),
-		kratos.Server(),
-	)
-
-	if err := app.Run(); err != nil {
+	if err := appStarter.Run(); err != nil {
 		panic(err)
 	}
 }
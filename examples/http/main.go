@@ -5,15 +5,15 @@ import (
 	"fmt"
 
 	"github.com/liuxiong332/kratos-starter/app"
+	"github.com/liuxiong332/kratos-starter/logger"
 
 	"github.com/gin-gonic/gin"
 	kgin "github.com/go-kratos/gin"
-	"github.com/go-kratos/kratos/v2"
 	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/middleware/recovery"
 	"github.com/go-kratos/kratos/v2/transport"
-	"github.com/go-kratos/kratos/v2/transport/http"
 )
 
 func customMiddleware(handler middleware.Handler) middleware.Handler {
@@ -32,9 +32,14 @@ func main() {
 	router := gin.Default()
 	// 使用kratos中间件
 	router.Use(kgin.Middlewares(recovery.Recovery(), customMiddleware))
+	// 每个请求的 logger 带上 request_id、operation 等字段
+	router.Use(logger.Gin(appStarter.Logger))
 
 	router.GET("/helloworld/:name", func(ctx *gin.Context) {
 		name := ctx.Param("name")
+		if l, ok := logger.FromContext(ctx.Request.Context()); ok {
+			log.NewHelper(l).Infof("welcome %s", name)
+		}
 		if name == "error" {
 			// 返回kratos error
 			kgin.Error(ctx, errors.Unauthorized("auth_error", "no authentication"))
@@ -43,19 +48,14 @@ func main() {
 		}
 	})
 
-	httpSrv := http.NewServer(http.Address(":8000"))
+	// 监听 server.http.address 或者 server.port
+	httpSrv, err := appStarter.NewHTTPServer()
+	if err != nil {
+		panic(err)
+	}
 	httpSrv.HandlePrefix("/", router)
 
-	app := kratos.New(
-		kratos.Name("hello-world"),
-		kratos.Version(""),
-		kratos.Metadata(map[string]string{}),
-		kratos.Logger(appStarter.Logger),
-		kratos.Registrar(appStarter.Registry),
-		kratos.Server(httpSrv),
-	)
-
-	if err := app.Run(); err != nil {
+	if err := appStarter.Run(); err != nil {
 		panic(err)
 	}
 }
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-zookeeper/zk"
+	consulApi "github.com/hashicorp/consul/api"
+	vaultApi "github.com/hashicorp/vault/api"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	machineryUtils "github.com/liuxiong332/kratos-starter/machinery"
+	redisUtils "github.com/liuxiong332/kratos-starter/redis"
+)
+
+// Consul checks the consul agent is reachable and the cluster has a leader.
+func Consul(client *consulApi.Client) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		leader, err := client.Status().LeaderWithQueryOptions((&consulApi.QueryOptions{}).WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if leader == "" {
+			return errors.New("consul has no leader")
+		}
+		return nil
+	})
+}
+
+// Vault checks the vault token of the client is valid.
+func Vault(client *vaultApi.Client) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		// the token lookup of the vault api is not bound to the ctx, the request
+		// is sent with the ctx like LookupSelfWithContext of the newer api
+		resp, err := client.RawRequestWithContext(ctx, client.NewRequest("GET", "/v1/auth/token/lookup-self"))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		secret, err := vaultApi.ParseSecret(resp.Body)
+		if err != nil {
+			return err
+		}
+		if secret == nil {
+			return errors.New("vault token lookup returns nothing")
+		}
+		return nil
+	})
+}
+
+// Mongo pings the primary of the mongo client.
+func Mongo(client *mongo.Client) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return client.Ping(ctx, nil)
+	})
+}
+
+// Redis pings the redis client.
+func Redis(client redis.UniversalClient) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+}
+
+// Zk checks the zookeeper connection has the session.
+func Zk(conn *zk.Conn) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if state := conn.State(); state != zk.StateHasSession {
+			return fmt.Errorf("zookeeper state is %s", state)
+		}
+		return nil
+	})
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// Machinery pings the redis broker of the machinery config, the returned closer
+// closes the redis client created for the check. Use Redis with the existing
+// client to share the connections.
+func Machinery(cfg machineryUtils.MachineryConfig) (Checker, io.Closer) {
+	if cfg.RedisConfig == nil {
+		return CheckerFunc(func(ctx context.Context) error {
+			return errors.New("machinery has no redis broker")
+		}), nopCloser{}
+	}
+	client := redisUtils.NewClient(cfg.RedisConfig)
+	return Redis(client), client
+}
@@ -0,0 +1,238 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Paths of the health handlers
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+// Status of the check
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Checker checks the health of one component, the component is healthy if no
+// error is returned.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is the function as the Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Option is the health registry option.
+type Option func(*Registry)
+
+// WithTimeout with the timeout of every check, default is 3s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(r *Registry) {
+		r.timeout = timeout
+	}
+}
+
+type check struct {
+	checker  Checker
+	liveness bool
+}
+
+// Registry is the registry of the checkers, the liveness checkers are checked
+// by the /healthz handler and all the checkers are checked by the /readyz
+// handler.
+type Registry struct {
+	timeout time.Duration
+
+	checks map[string]check
+	lock   sync.RWMutex
+}
+
+// New creates the health registry.
+func New(opts ...Option) *Registry {
+	r := &Registry{
+		timeout: 3 * time.Second,
+		checks:  make(map[string]check),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// Register registers the readiness checker, the checker of the same name is
+// replaced.
+func (r *Registry) Register(name string, checker Checker) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.checks[name] = check{checker: checker}
+}
+
+// RegisterLiveness registers the liveness checker, it is also checked for the
+// readiness.
+func (r *Registry) RegisterLiveness(name string, checker Checker) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.checks[name] = check{checker: checker, liveness: true}
+}
+
+// Unregister removes the checker.
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.checks, name)
+}
+
+// CheckResult is the result of one checker.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Result is the result of the checkers, the status is up only if all the
+// checkers are up.
+type Result struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Up reports whether all the checkers are up.
+func (r Result) Up() bool {
+	return r.Status == StatusUp
+}
+
+// CheckLiveness runs the liveness checkers concurrently.
+func (r *Registry) CheckLiveness(ctx context.Context) Result {
+	return r.run(ctx, true)
+}
+
+// CheckReadiness runs all the checkers concurrently.
+func (r *Registry) CheckReadiness(ctx context.Context) Result {
+	return r.run(ctx, false)
+}
+
+func (r *Registry) run(ctx context.Context, livenessOnly bool) Result {
+	r.lock.RLock()
+	checks := make(map[string]Checker, len(r.checks))
+	for name, c := range r.checks {
+		if !livenessOnly || c.liveness {
+			checks[name] = c.checker
+		}
+	}
+	r.lock.RUnlock()
+
+	result := Result{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for name, checker := range checks {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+			checkResult := r.runCheck(ctx, checker)
+
+			lock.Lock()
+			defer lock.Unlock()
+			result.Checks[name] = checkResult
+			if checkResult.Status != StatusUp {
+				result.Status = StatusDown
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+	return result
+}
+
+func (r *Registry) runCheck(ctx context.Context, checker Checker) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start).String()
+	}()
+
+	// the checker may not respect the ctx
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return CheckResult{Status: StatusDown, Error: err.Error()}
+	}
+	return CheckResult{Status: StatusUp}
+}
+
+// Names returns the sorted names of the checkers.
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LivenessHandler returns the /healthz handler, it responds 503 if any
+// liveness checker is down.
+func (r *Registry) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeResult(w, r.CheckLiveness(req.Context()))
+	})
+}
+
+// ReadinessHandler returns the /readyz handler, it responds 503 if any checker
+// is down.
+func (r *Registry) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeResult(w, r.CheckReadiness(req.Context()))
+	})
+}
+
+// Handler is the http server which the handlers can be mounted on, like the
+// kratos http server.
+type Handler interface {
+	Handle(path string, h http.Handler)
+}
+
+// Mount mounts the /healthz and /readyz handlers on the server.
+func (r *Registry) Mount(srv Handler) {
+	srv.Handle(LivenessPath, r.LivenessHandler())
+	srv.Handle(ReadinessPath, r.ReadinessHandler())
+}
+
+func writeResult(w http.ResponseWriter, result Result) {
+	w.Header().Set("Content-Type", "application/json")
+	if result.Up() {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	consulApi "github.com/hashicorp/consul/api"
+	vaultApi "github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+
+	machineryUtils "github.com/liuxiong332/kratos-starter/machinery"
+)
+
+func TestRegistry(t *testing.T) {
+	r := New(WithTimeout(50 * time.Millisecond))
+	r.RegisterLiveness("self", CheckerFunc(func(ctx context.Context) error { return nil }))
+	r.Register("db", CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") }))
+	r.Register("slow", CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+	assert.Equal(t, []string{"db", "self", "slow"}, r.Names())
+
+	liveness := r.CheckLiveness(context.Background())
+	assert.True(t, liveness.Up())
+	assert.Len(t, liveness.Checks, 1)
+
+	readiness := r.CheckReadiness(context.Background())
+	assert.False(t, readiness.Up())
+	assert.Equal(t, StatusUp, readiness.Checks["self"].Status)
+	assert.Equal(t, "connection refused", readiness.Checks["db"].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), readiness.Checks["slow"].Error)
+}
+
+func TestHandlers(t *testing.T) {
+	r := New()
+	r.Register("panic", CheckerFunc(func(ctx context.Context) error { panic("boom") }))
+	mux := http.NewServeMux()
+	r.Mount(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", LivenessPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", ReadinessPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var result Result
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, StatusDown, result.Status)
+	assert.Equal(t, "panic: boom", result.Checks["panic"].Error)
+}
+
+func TestCheckersContext(t *testing.T) {
+	// the server hangs until the test ends, the checkers return by the ctx
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	consulClient, err := consulApi.NewClient(&consulApi.Config{Address: srv.URL})
+	assert.NoError(t, err)
+	vaultClient, err := vaultApi.NewClient(&vaultApi.Config{Address: srv.URL})
+	assert.NoError(t, err)
+
+	for name, checker := range map[string]Checker{"consul": Consul(consulClient), "vault": Vault(vaultClient)} {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		start := time.Now()
+		assert.Error(t, checker.Check(ctx), name)
+		assert.Less(t, time.Since(start), 5*time.Second, name)
+		cancel()
+	}
+}
+
+func TestMachineryNoBroker(t *testing.T) {
+	checker, closer := Machinery(machineryUtils.MachineryConfig{})
+	assert.Error(t, checker.Check(context.Background()))
+	assert.NoError(t, closer.Close())
+}
@@ -50,15 +50,28 @@ func (o ContentTypeCallOption) before(c *callInfo) error {
 	return nil
 }
 
-func defaultCallInfo(path string) callInfo {
+// unknownOperation is the operation of the call without the operation and
+// path template, the raw path is not used since it may contain the IDs.
+const unknownOperation = "unknown"
+
+func defaultCallInfo() callInfo {
 	return callInfo{
-		contentType:  "application/json",
-		operation:    path,
-		pathTemplate: path,
+		contentType: "application/json",
+	}
+}
+
+// operationName returns the operation, or the path template, of the call for
+// the metrics and the span name, empty if neither is set.
+func (c callInfo) operationName() string {
+	if c.operation != "" {
+		return c.operation
 	}
+	return c.pathTemplate
 }
 
-// Operation is serviceMethod call option
+// Operation is serviceMethod call option, it is the operation label of the
+// client metrics and the name of the client span, like /api.user.v1.User/Get.
+// The path template is used if not set, and "unknown" if neither is set.
 func Operation(operation string) CallOption {
 	return OperationCallOption{Operation: operation}
 }
@@ -74,7 +87,7 @@ func (o OperationCallOption) before(c *callInfo) error {
 	return nil
 }
 
-// PathTemplate is http path template
+// PathTemplate is http path template, like /users/{id}
 func PathTemplate(pattern string) CallOption {
 	return PathTemplateCallOption{Pattern: pattern}
 }
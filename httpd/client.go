@@ -17,8 +17,17 @@ import (
 	"github.com/go-kratos/kratos/v2/registry"
 	"github.com/go-kratos/kratos/v2/selector"
 	"github.com/go-kratos/kratos/v2/selector/wrr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/liuxiong332/kratos-starter/metrics"
 )
 
+const tracerName = "github.com/liuxiong332/kratos-starter/httpd"
+
 func init() {
 	if selector.GlobalSelector() == nil {
 		selector.SetGlobalSelector(wrr.NewBuilder())
@@ -218,17 +227,34 @@ func NewClient(ctx context.Context, opts ...ClientOption) (*Client, error) {
 // Do send an HTTP request and decodes the body of response into target.
 // returns an error (of type *Error) if the response status code is not 2xx.
 func (client *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, error) {
-	c := defaultCallInfo(req.URL.Path)
+	c := defaultCallInfo()
 	for _, o := range opts {
 		if err := o.before(&c); err != nil {
 			return nil, err
 		}
 	}
 
-	return client.do(req)
+	resp, err := client.do(req, c)
+	// the after hooks extract the response, like the Header call option
+	for _, o := range opts {
+		o.after(&c, &csAttempt{res: resp})
+	}
+	return resp, err
 }
 
-func (client *Client) do(req *http.Request) (*http.Response, error) {
+func (client *Client) do(req *http.Request, c callInfo) (*http.Response, error) {
+	start := time.Now()
+	operation := c.operationName()
+	spanName := operation
+	if operation == "" {
+		operation, spanName = unknownOperation, "HTTP "+req.Method
+	}
+	// the client span is the child of the span in the request context, and
+	// the trace context is propagated by the request header
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), spanName, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	req = req.WithContext(ctx)
+
 	var done func(context.Context, selector.DoneInfo)
 	if client.r != nil {
 		var (
@@ -246,9 +272,23 @@ func (client *Client) do(req *http.Request) (*http.Response, error) {
 		req.URL.Host = node.Address()
 		req.Host = node.Address()
 	}
+	span.SetAttributes(
+		attribute.String("http.method", req.Method),
+		attribute.String("http.url", req.URL.String()),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
 	resp, err := client.cc.Do(req)
 	if err == nil {
+		metrics.Default().HTTPClientRequest(operation, resp.StatusCode, time.Since(start))
+		span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
 		err = client.opts.errorDecoder(req.Context(), resp)
+	} else {
+		metrics.Default().HTTPClientRequest(operation, 0, time.Since(start))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
 	if done != nil {
 		done(req.Context(), selector.DoneInfo{Err: err})
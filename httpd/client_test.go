@@ -0,0 +1,70 @@
+package httpd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liuxiong332/kratos-starter/metrics"
+)
+
+func TestClientMetricsOperation(t *testing.T) {
+	m := metrics.New()
+	metrics.Enable(m)
+	defer metrics.Enable(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	client, err := NewClient(context.Background(), WithEndpoint(srv.URL))
+	assert.NoError(t, err)
+
+	do := func(path string, opts ...CallOption) {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		resp, err := client.Do(req, opts...)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+	do("/users/1")
+	do("/users/2")
+	do("/users/3", PathTemplate("/users/{id}"))
+	do("/users/4", Operation("/api.user.v1.User/Get"), PathTemplate("/users/{id}"))
+
+	families, err := m.Registry().Gather()
+	assert.NoError(t, err)
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != "kratos_starter_http_client_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "operation" {
+					counts[label.GetValue()] = metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	// the raw paths are not the labels
+	assert.Equal(t, map[string]uint64{"unknown": 2, "/users/{id}": 1, "/api.user.v1.User/Get": 1}, counts)
+}
+
+func TestClientHeaderCallOption(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc")
+	}))
+	defer srv.Close()
+	client, err := NewClient(context.Background(), WithEndpoint(srv.URL))
+	assert.NoError(t, err)
+
+	var header http.Header
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/hello", nil)
+	resp, err := client.Do(req, Header(&header))
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+	assert.Equal(t, "abc", header.Get("X-Request-Id"))
+}
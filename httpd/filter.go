@@ -0,0 +1,42 @@
+package httpd
+
+import (
+	"context"
+
+	"github.com/go-kratos/kratos/v2/selector"
+)
+
+// MetadataFilter is the node filter which keeps the nodes whose metadata of
+// the key is one of the values, like MetadataFilter("zone", "us-east-1a").
+func MetadataFilter(key string, values ...string) selector.NodeFilter {
+	return func(_ context.Context, nodes []selector.Node) []selector.Node {
+		newNodes := make([]selector.Node, 0, len(nodes))
+		for _, n := range nodes {
+			if containsValue(values, n.Metadata()[key]) {
+				newNodes = append(newNodes, n)
+			}
+		}
+		return newNodes
+	}
+}
+
+// PreferMetadataFilter is like MetadataFilter, but keeps all the nodes if no
+// node matches, like preferring the nodes in the same zone.
+func PreferMetadataFilter(key string, values ...string) selector.NodeFilter {
+	filter := MetadataFilter(key, values...)
+	return func(ctx context.Context, nodes []selector.Node) []selector.Node {
+		if newNodes := filter(ctx, nodes); len(newNodes) > 0 {
+			return newNodes
+		}
+		return nodes
+	}
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
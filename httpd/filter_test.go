@@ -0,0 +1,24 @@
+package httpd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/go-kratos/kratos/v2/selector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataFilter(t *testing.T) {
+	nodes := []selector.Node{
+		selector.NewNode("http", "127.0.0.1:8000", &registry.ServiceInstance{Metadata: map[string]string{"zone": "a"}}),
+		selector.NewNode("http", "127.0.0.1:8001", &registry.ServiceInstance{Metadata: map[string]string{"zone": "b"}}),
+	}
+
+	filtered := MetadataFilter("zone", "a")(context.Background(), nodes)
+	if assert.Len(t, filtered, 1) {
+		assert.Equal(t, "127.0.0.1:8000", filtered[0].Address())
+	}
+	assert.Empty(t, MetadataFilter("zone", "c")(context.Background(), nodes))
+	assert.Len(t, PreferMetadataFilter("zone", "c")(context.Background(), nodes), 2)
+}
@@ -0,0 +1,265 @@
+// Package audit records the security-relevant events in the hash-chained
+// audit log, which is separate from the application log.
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/liuxiong332/kratos-starter/logger"
+)
+
+// Events of the starter components
+const (
+	EventConfigChanged      = "config.changed"
+	EventLeadershipAcquired = "leadership.acquired"
+	EventLeadershipLost     = "leadership.lost"
+	EventVaultRead          = "vault.read"
+)
+
+// Outcomes of the event
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Config is the config of the audit block.
+type Config struct {
+	// Outputs are the outputs of the audit log, default is the file
+	// ./logs/audit.log. At most one file output is allowed, the chain is
+	// resumed from its last record.
+	Outputs []logger.OutputConfig `json:"outputs"`
+	// HMACKey signs the chain, the chain can not be rebuilt without it
+	HMACKey string `json:"hmac_key"`
+	// Actor is the default actor of the events, like the app name
+	Actor string `json:"actor"`
+}
+
+// Event is the audit event.
+type Event struct {
+	Type    string
+	Actor   string
+	Target  string
+	Outcome string
+	// Details must not contain the secrets, they are not masked
+	Details map[string]string
+}
+
+// Record is the line of the audit log. The hash is the SHA256, or the
+// HMAC-SHA256 if keyed, of the json record without the hash, so every record
+// is chained to the previous one.
+type Record struct {
+	Seq      uint64            `json:"seq"`
+	Time     string            `json:"time"`
+	Event    string            `json:"event"`
+	Actor    string            `json:"actor"`
+	Target   string            `json:"target"`
+	Outcome  string            `json:"outcome"`
+	Details  map[string]string `json:"details,omitempty"`
+	PrevHash string            `json:"prev_hash"`
+	Hash     string            `json:"hash"`
+}
+
+// Sum returns the hash of the record by the key.
+func (r Record) Sum(key []byte) string {
+	r.Hash = ""
+	data, _ := json.Marshal(r)
+	var sum []byte
+	if len(key) > 0 {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+	} else {
+		h := sha256.Sum256(data)
+		sum = h[:]
+	}
+	return hex.EncodeToString(sum)
+}
+
+// encoderConfig writes the records as the json lines without the log fields.
+var encoderConfig = zapcore.EncoderConfig{
+	LineEnding:     zapcore.DefaultLineEnding,
+	EncodeDuration: zapcore.StringDurationEncoder,
+	EncodeTime:     zapcore.ISO8601TimeEncoder,
+}
+
+// Logger writes the audit records. The methods do nothing on the nil *Logger,
+// so the components record the events only if the audit log is enabled.
+type Logger struct {
+	core   zapcore.Core
+	closer io.Closer
+	key    []byte
+	actor  string
+
+	lock     sync.Mutex
+	seq      uint64
+	prevHash string
+}
+
+// DefaultConfig returns the config writing to ./logs/audit.log.
+func DefaultConfig() *Config {
+	return &Config{Outputs: []logger.OutputConfig{{Type: logger.OutputFile, Path: "./logs/audit.log"}}}
+}
+
+// ParseConfig scans the audit config over the default config.
+func ParseConfig(c config.Config) (*Config, error) {
+	auditConfig := DefaultConfig()
+	if c.Value("audit.outputs").Load() != nil {
+		auditConfig.Outputs = nil
+	}
+	if err := c.Value("audit").Scan(auditConfig); err != nil && !errors.Is(err, config.ErrNotFound) {
+		return nil, err
+	}
+	return auditConfig, nil
+}
+
+// New creates the audit logger, the logger should be closed. More than one
+// file output is refused, the chains of the files could not be resumed
+// together.
+func New(c *Config) (*Logger, error) {
+	l := &Logger{key: []byte(c.HMACKey), actor: c.Actor}
+	var filePath string
+	for _, output := range c.Outputs {
+		if strings.EqualFold(output.Type, logger.OutputFile) || (output.Type == "" && output.Path != "") {
+			if filePath != "" {
+				return nil, fmt.Errorf("audit log has more than one file output: %s, %s", filePath, output.Path)
+			}
+			filePath = output.Path
+		}
+	}
+	if filePath != "" {
+		last, err := lastRecord(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("resume audit log %s: %w", filePath, err)
+		}
+		if last != nil {
+			l.seq, l.prevHash = last.Seq, last.Hash
+		}
+	}
+
+	core, closer, err := logger.NewCore(c.Outputs, encoderConfig)
+	if err != nil {
+		return nil, err
+	}
+	l.core, l.closer = core, closer
+	return l, nil
+}
+
+// lastRecord returns the last record of the file, nil if the file is not
+// found or empty.
+func lastRecord(path string) (*Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(line) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if last == nil {
+		return nil, nil
+	}
+	record := &Record{}
+	if err := json.Unmarshal(last, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Record writes the record of the event chained to the previous record. The
+// chain advances only if the record is written, so the next record after a
+// failed write is chained to the last written one.
+func (l *Logger) Record(event Event) error {
+	if l == nil {
+		return nil
+	}
+	if event.Actor == "" {
+		event.Actor = l.actor
+	}
+	if event.Outcome == "" {
+		event.Outcome = OutcomeSuccess
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	record := Record{
+		Seq:      l.seq + 1,
+		Time:     time.Now().UTC().Format(time.RFC3339Nano),
+		Event:    event.Type,
+		Actor:    event.Actor,
+		Target:   event.Target,
+		Outcome:  event.Outcome,
+		Details:  event.Details,
+		PrevHash: l.prevHash,
+	}
+	record.Hash = record.Sum(l.key)
+
+	fields := []zapcore.Field{
+		zap.Uint64("seq", record.Seq),
+		zap.String("time", record.Time),
+		zap.String("event", record.Event),
+		zap.String("actor", record.Actor),
+		zap.String("target", record.Target),
+		zap.String("outcome", record.Outcome),
+	}
+	if len(record.Details) > 0 {
+		fields = append(fields, zap.Any("details", record.Details))
+	}
+	fields = append(fields, zap.String("prev_hash", record.PrevHash), zap.String("hash", record.Hash))
+
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: event.Type}
+	if err := l.core.Write(ent, fields); err != nil {
+		return fmt.Errorf("write audit record: %w", err)
+	}
+	l.seq, l.prevHash = record.Seq, record.Hash
+	return l.core.Sync()
+}
+
+// Close closes the outputs of the audit log.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	_ = l.core.Sync()
+	return l.closer.Close()
+}
+
+var enabled atomic.Pointer[Logger]
+
+// Enable sets the audit logger used by the starter components, nil disables
+// it.
+func Enable(l *Logger) {
+	enabled.Store(l)
+}
+
+// Default returns the enabled audit logger, nil if not enabled.
+func Default() *Logger {
+	return enabled.Load()
+}
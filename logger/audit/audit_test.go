@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/liuxiong332/kratos-starter/logger"
+)
+
+func newTestLogger(t *testing.T, path string, key string) *Logger {
+	l, err := New(&Config{
+		Outputs: []logger.OutputConfig{{Type: logger.OutputFile, Path: path}},
+		HMACKey: key,
+		Actor:   "test",
+	})
+	assert.NoError(t, err)
+	return l
+}
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := newTestLogger(t, path, "")
+	assert.NoError(t, l.Record(Event{Type: EventConfigChanged, Target: "server", Details: map[string]string{"diff": "port: 80 -> 8080"}}))
+	assert.NoError(t, l.Record(Event{Type: EventLeadershipAcquired, Target: "/leader"}))
+	assert.NoError(t, l.Close())
+
+	// the chain is resumed from the file
+	l = newTestLogger(t, path, "")
+	assert.NoError(t, l.Record(Event{Type: EventVaultRead, Target: "secret/test", Outcome: OutcomeFailure}))
+	assert.NoError(t, l.Close())
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	last, err := Verify(f)
+	assert.NoError(t, err)
+	if assert.NotNil(t, last) {
+		assert.Equal(t, uint64(3), last.Seq)
+		assert.Equal(t, EventVaultRead, last.Event)
+		assert.Equal(t, "test", last.Actor)
+		assert.Equal(t, OutcomeFailure, last.Outcome)
+	}
+
+	// the nil logger records nothing
+	var nilLogger *Logger
+	assert.NoError(t, nilLogger.Record(Event{Type: EventVaultRead}))
+	assert.NoError(t, nilLogger.Close())
+}
+
+func TestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := newTestLogger(t, path, "secret-key")
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.Record(Event{Type: EventConfigChanged, Target: "server"}))
+	}
+	assert.NoError(t, l.Close())
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.SplitAfter(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 3)
+
+	verify := func(log string, opts ...VerifyOption) (int, error) {
+		_, err := Verify(strings.NewReader(log), opts...)
+		var verifyErr *VerifyError
+		if errors.As(err, &verifyErr) {
+			return verifyErr.Line, err
+		}
+		return 0, err
+	}
+
+	_, err = verify(string(data), WithKey("secret-key"))
+	assert.NoError(t, err)
+	// the hash can not be verified without the key
+	line, err := verify(string(data))
+	assert.Error(t, err)
+	assert.Equal(t, 1, line)
+
+	// the modified record
+	tampered := strings.Replace(string(data), `"target":"server"`, `"target":"client"`, 1)
+	line, err = verify(tampered, WithKey("secret-key"))
+	assert.Error(t, err)
+	assert.Equal(t, 1, line)
+
+	// the removed record
+	line, err = verify(lines[0]+lines[2], WithKey("secret-key"))
+	assert.Error(t, err)
+	assert.Equal(t, 2, line)
+	// the removed oldest record
+	line, err = verify(lines[1]+lines[2], WithKey("secret-key"))
+	assert.Error(t, err)
+	assert.Equal(t, 1, line)
+
+	// the rotated file is verified by the continuation of the previous file
+	last, err := Verify(strings.NewReader(lines[0]), WithKey("secret-key"))
+	assert.NoError(t, err)
+	_, err = verify(lines[1]+lines[2], WithKey("secret-key"), WithContinuation(last.Seq, last.Hash))
+	assert.NoError(t, err)
+	line, err = verify(lines[2], WithKey("secret-key"), WithContinuation(last.Seq, last.Hash))
+	assert.Error(t, err)
+	assert.Equal(t, 1, line)
+}
+
+func TestEnable(t *testing.T) {
+	assert.Nil(t, Default())
+	l := newTestLogger(t, filepath.Join(t.TempDir(), "audit.log"), "")
+	Enable(l)
+	defer Enable(nil)
+	assert.Equal(t, l, Default())
+	assert.NoError(t, Default().Record(Event{Type: EventLeadershipLost}))
+	assert.NoError(t, l.Close())
+}
+
+// failCore fails the writes while fail is set.
+type failCore struct {
+	zapcore.Core
+	fail bool
+}
+
+func (c *failCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.fail {
+		return errors.New("disk full")
+	}
+	return c.Core.Write(ent, fields)
+}
+
+func TestRecordWriteError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := newTestLogger(t, path, "")
+	core := &failCore{Core: l.core}
+	l.core = core
+	assert.NoError(t, l.Record(Event{Type: EventConfigChanged}))
+
+	// the failed record does not advance the chain
+	core.fail = true
+	assert.Error(t, l.Record(Event{Type: EventConfigChanged}))
+	core.fail = false
+	assert.NoError(t, l.Record(Event{Type: EventConfigChanged}))
+	assert.NoError(t, l.Close())
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	last, err := Verify(f)
+	assert.NoError(t, err)
+	if assert.NotNil(t, last) {
+		assert.Equal(t, uint64(2), last.Seq)
+	}
+}
+
+func TestNewFileOutputs(t *testing.T) {
+	dir := t.TempDir()
+	_, err := New(&Config{Outputs: []logger.OutputConfig{
+		{Type: logger.OutputFile, Path: filepath.Join(dir, "a.log")},
+		{Type: logger.OutputFile, Path: filepath.Join(dir, "b.log")},
+	}})
+	assert.Error(t, err)
+}
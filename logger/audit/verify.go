@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxLineSize is the max size of the record line.
+const maxLineSize = 1024 * 1024
+
+// VerifyError is the broken record of the audit log.
+type VerifyError struct {
+	// Line is the line number of the record
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("audit log line %d (seq %d): %s", e.Line, e.Seq, e.Reason)
+}
+
+// VerifyOption is the option of Verify.
+type VerifyOption func(o *verifyOptions)
+
+type verifyOptions struct {
+	key      []byte
+	seq      uint64
+	prevHash string
+}
+
+// WithKey verifies the records signed by the HMAC key.
+func WithKey(key string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.key = []byte(key)
+	}
+}
+
+// WithContinuation verifies the log continued from the record of the seq and
+// hash, like the log rotated from the previous file. By default the log must
+// start from the first record, seq 1 with the empty previous hash.
+func WithContinuation(seq uint64, prevHash string) VerifyOption {
+	return func(o *verifyOptions) {
+		o.seq, o.prevHash = seq, prevHash
+	}
+}
+
+// Verify validates the hash chain of the audit log, and returns the last
+// record verified. The error is the *VerifyError of the first broken record.
+func Verify(r io.Reader, opts ...VerifyOption) (*Record, error) {
+	o := &verifyOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	var last *Record
+	seq, prevHash := o.seq, o.prevHash
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		record := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return last, &VerifyError{Line: line, Seq: seq + 1, Reason: fmt.Sprintf("invalid record: %v", err)}
+		}
+		if record.Seq != seq+1 {
+			return last, &VerifyError{Line: line, Seq: record.Seq, Reason: fmt.Sprintf("expected seq %d", seq+1)}
+		}
+		if record.PrevHash != prevHash {
+			return last, &VerifyError{Line: line, Seq: record.Seq, Reason: "previous hash mismatch"}
+		}
+		if record.Sum(o.key) != record.Hash {
+			return last, &VerifyError{Line: line, Seq: record.Seq, Reason: "hash mismatch"}
+		}
+		seq, prevHash, last = record.Seq, record.Hash, record
+	}
+	if err := scanner.Err(); err != nil {
+		return last, err
+	}
+	return last, nil
+}
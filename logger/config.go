@@ -0,0 +1,260 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/liuxiong332/kratos-starter/logger/sink"
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+	"github.com/liuxiong332/kratos-starter/secret"
+)
+
+// Output types
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+	OutputHTTP   = "http"
+	OutputGELF   = "gelf"
+)
+
+// Encoders of the output
+const (
+	EncoderJSON    = "json"
+	EncoderConsole = "console"
+)
+
+// RotationConfig is the lumberjack rotation of the file output.
+type RotationConfig struct {
+	// MaxSize is the megabytes of the file before rotated
+	MaxSize    int `json:"max_size"`
+	MaxBackups int `json:"max_backups"`
+	// MaxAge is the days to retain the rotated files
+	MaxAge    int  `json:"max_age"`
+	Compress  bool `json:"compress"`
+	LocalTime bool `json:"local_time"`
+}
+
+// OutputConfig is the config of one log output.
+type OutputConfig struct {
+	// Type is stdout, stderr, file, syslog, http or gelf, default is file if
+	// the path is set, otherwise stdout
+	Type string `json:"type"`
+	Path string `json:"path"`
+	// Encoder is json or console, default is json
+	Encoder string `json:"encoder"`
+	// Level is the min level of the output, the logger level is used if empty
+	Level    string          `json:"level"`
+	Rotation *RotationConfig `json:"rotation"`
+	// Syslog, HTTP and GELF are the sink config of the output type
+	Syslog *sink.SyslogConfig `json:"syslog"`
+	HTTP   *sink.HTTPConfig   `json:"http"`
+	GELF   *sink.GELFConfig   `json:"gelf"`
+}
+
+// SamplingConfig logs the first Initial entries of the same level and message
+// in every Tick, then every Thereafter entry.
+type SamplingConfig struct {
+	Initial    int    `json:"initial"`
+	Thereafter int    `json:"thereafter"`
+	Tick       string `json:"tick"`
+}
+
+// RateLimitConfig limits the entries of the same module, level and message,
+// the suppressed entries are summarized every SummaryInterval.
+type RateLimitConfig struct {
+	// Rate is the entries per second of every message, 0 disables the limit
+	Rate float64 `json:"rate"`
+	// Burst defaults to the rate
+	Burst int `json:"burst"`
+	// SummaryInterval is the interval of the suppressed summaries, default is
+	// 10s
+	SummaryInterval string `json:"summary_interval"`
+	// Modules are the limits of the modules, see zapLog.ModuleKey
+	Modules map[string]zapLog.RateLimit `json:"modules"`
+}
+
+// LoggerConfig is the config of the log block.
+type LoggerConfig struct {
+	Level string `json:"level"`
+	// Modules are the levels of the modules, see zapLog.ModuleKey
+	Modules map[string]string `json:"modules"`
+	Outputs []OutputConfig    `json:"outputs"`
+	// Sampling is disabled if nil
+	Sampling *SamplingConfig `json:"sampling"`
+	// RateLimit is disabled if nil
+	RateLimit *RateLimitConfig `json:"rate_limit"`
+	// CallerSkip is the caller frames skipped, the default skips the kratos
+	// log helper
+	CallerSkip    int  `json:"caller_skip"`
+	DisableCaller bool `json:"disable_caller"`
+	// StacktraceLevel is the min level to record the stack trace
+	StacktraceLevel string `json:"stacktrace_level"`
+	// Fields are added to every log
+	Fields map[string]interface{} `json:"fields"`
+	// Masker redacts the secrets of the logs, default is secret.Default()
+	Masker *secret.Masker `json:"-"`
+}
+
+// DefaultConfig returns the config of NewLogger: the json logs of info level
+// are written to the stdout and ./logs/elk.log rotated by 10MB, 3 backups and
+// 3 days.
+func DefaultConfig() *LoggerConfig {
+	return &LoggerConfig{
+		Level: "info",
+		Outputs: []OutputConfig{
+			{Type: OutputStdout, Encoder: EncoderJSON},
+			{
+				Type:     OutputFile,
+				Path:     "./logs/elk.log",
+				Encoder:  EncoderJSON,
+				Rotation: &RotationConfig{MaxSize: 10, MaxBackups: 3, MaxAge: 3},
+			},
+		},
+		CallerSkip:      3,
+		StacktraceLevel: "error",
+	}
+}
+
+// ParseLoggerConfig scans the log config over the default config, the outputs
+// replace the default outputs if set.
+func ParseLoggerConfig(c config.Config) (*LoggerConfig, error) {
+	loggerConfig := DefaultConfig()
+	if c.Value("log.outputs").Load() != nil {
+		// json decodes the outputs into the default outputs in place
+		loggerConfig.Outputs = nil
+	}
+	if err := c.Value("log").Scan(loggerConfig); err != nil && !errors.Is(err, config.ErrNotFound) {
+		return nil, err
+	}
+	if err := loggerConfig.validate(); err != nil {
+		return nil, err
+	}
+	return loggerConfig, nil
+}
+
+// UpdateLevels sets the levels by the log.level and log.modules config, the
+// overrides of the levels are kept.
+func UpdateLevels(levels *zapLog.Levels, c config.Config) error {
+	// the keys are read one by one, the cached log block may be stale when the
+	// keys are observed
+	loggerConfig := &LoggerConfig{Level: "info"}
+	if level, err := c.Value("log.level").String(); err == nil {
+		loggerConfig.Level = level
+	}
+	if err := c.Value("log.modules").Scan(&loggerConfig.Modules); err != nil && !errors.Is(err, config.ErrNotFound) {
+		return err
+	}
+	level, err := parseLevel(loggerConfig.Level)
+	if err != nil {
+		return err
+	}
+	modules, err := loggerConfig.moduleLevels()
+	if err != nil {
+		return err
+	}
+	levels.SetLevels(level, modules)
+	return nil
+}
+
+func (c *LoggerConfig) validate() error {
+	if _, err := parseLevel(c.Level); err != nil {
+		return err
+	}
+	if _, err := parseLevel(c.StacktraceLevel); err != nil {
+		return err
+	}
+	if _, err := c.moduleLevels(); err != nil {
+		return err
+	}
+	for i, output := range c.Outputs {
+		switch output.outputType() {
+		case OutputStdout, OutputStderr:
+		case OutputFile:
+			if output.Path == "" {
+				return fmt.Errorf("log output %d: file path is required", i)
+			}
+		case OutputSyslog:
+			if output.Syslog == nil {
+				return fmt.Errorf("log output %d: syslog config is required", i)
+			}
+		case OutputHTTP:
+			if output.HTTP == nil {
+				return fmt.Errorf("log output %d: http config is required", i)
+			}
+		case OutputGELF:
+			if output.GELF == nil {
+				return fmt.Errorf("log output %d: gelf config is required", i)
+			}
+		default:
+			return fmt.Errorf("log output %d: unknown type %s", i, output.Type)
+		}
+		switch output.Encoder {
+		case "", EncoderJSON, EncoderConsole:
+		default:
+			return fmt.Errorf("log output %d: unknown encoder %s", i, output.Encoder)
+		}
+		if _, err := parseLevel(output.Level); err != nil {
+			return fmt.Errorf("log output %d: %w", i, err)
+		}
+	}
+	if c.RateLimit != nil && c.RateLimit.SummaryInterval != "" {
+		if d, err := time.ParseDuration(c.RateLimit.SummaryInterval); err != nil || d <= 0 {
+			return fmt.Errorf("log rate limit summary interval: invalid %s", c.RateLimit.SummaryInterval)
+		}
+	}
+	if c.Sampling != nil && c.Sampling.Tick != "" {
+		if _, err := time.ParseDuration(c.Sampling.Tick); err != nil {
+			return fmt.Errorf("log sampling tick: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *LoggerConfig) moduleLevels() (map[string]zapcore.Level, error) {
+	modules := make(map[string]zapcore.Level, len(c.Modules))
+	for module, s := range c.Modules {
+		level, err := parseLevel(s)
+		if err != nil {
+			return nil, fmt.Errorf("module %s: %w", module, err)
+		}
+		modules[module] = level
+	}
+	return modules, nil
+}
+
+// OutputNames returns the outputs like stdout and file:./logs/elk.log.
+func (c *LoggerConfig) OutputNames() []string {
+	names := make([]string, 0, len(c.Outputs))
+	for _, output := range c.Outputs {
+		switch t := output.outputType(); {
+		case t == OutputFile:
+			names = append(names, t+":"+output.Path)
+		case t == OutputSyslog && output.Syslog != nil:
+			names = append(names, t+":"+output.Syslog.Address)
+		case t == OutputHTTP && output.HTTP != nil:
+			names = append(names, t+":"+output.HTTP.URL)
+		case t == OutputGELF && output.GELF != nil:
+			names = append(names, t+":"+output.GELF.Address)
+		default:
+			names = append(names, t)
+		}
+	}
+	return names
+}
+
+func (o OutputConfig) outputType() string {
+	if o.Type != "" {
+		return strings.ToLower(o.Type)
+	}
+	if o.Path != "" {
+		return OutputFile
+	}
+	return OutputStdout
+}
@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/peer"
+)
+
+// RequestIDHeader is the header of the request ID, the ID is generated if the
+// request has no ID, and written to the reply header.
+const RequestIDHeader = "X-Request-Id"
+
+// Fields of the request logger
+const (
+	FieldRequestID  = "request_id"
+	FieldOperation  = "operation"
+	FieldRemoteAddr = "remote_addr"
+	FieldTraceID    = "trace_id"
+	FieldSpanID     = "span_id"
+)
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// ContextOption is the option of the request logger.
+type ContextOption func(*contextOptions)
+
+type contextOptions struct {
+	header string
+	keys   []contextKey
+}
+
+type contextKey struct {
+	field string
+	key   interface{}
+}
+
+// WithContextKey adds the value of the context key as the field.
+func WithContextKey(field string, key interface{}) ContextOption {
+	return func(o *contextOptions) {
+		o.keys = append(o.keys, contextKey{field: field, key: key})
+	}
+}
+
+// WithRequestIDHeader with the header of the request ID, default is
+// RequestIDHeader.
+func WithRequestIDHeader(header string) ContextOption {
+	return func(o *contextOptions) {
+		o.header = header
+	}
+}
+
+func newContextOptions(opts []ContextOption) contextOptions {
+	o := contextOptions{header: RequestIDHeader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// NewContext returns the ctx with the logger.
+func NewContext(ctx context.Context, logger log.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the ctx installed by the middleware.
+func FromContext(ctx context.Context) (log.Logger, bool) {
+	logger, ok := ctx.Value(loggerKey{}).(log.Logger)
+	return logger, ok
+}
+
+// RequestID returns the request ID of the ctx installed by the middleware.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithContext returns the logger with the request ID, operation, remote
+// address, trace IDs and the context keys of the ctx, the fields not found are
+// omitted.
+func WithContext(ctx context.Context, logger log.Logger, opts ...ContextOption) log.Logger {
+	o := newContextOptions(opts)
+	var keyvals []interface{}
+	if id := RequestID(ctx); id != "" {
+		keyvals = append(keyvals, FieldRequestID, id)
+	}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		keyvals = append(keyvals, FieldOperation, tr.Operation())
+	}
+	if addr := remoteAddr(ctx); addr != "" {
+		keyvals = append(keyvals, FieldRemoteAddr, addr)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		keyvals = append(keyvals, FieldTraceID, sc.TraceID().String(), FieldSpanID, sc.SpanID().String())
+	}
+	for _, k := range o.keys {
+		if v := ctx.Value(k.key); v != nil {
+			keyvals = append(keyvals, k.field, v)
+		}
+	}
+	if len(keyvals) == 0 {
+		return logger
+	}
+	return log.With(logger, keyvals...)
+}
+
+func remoteAddr(ctx context.Context) string {
+	if req, ok := http.RequestFromServerContext(ctx); ok {
+		return req.RemoteAddr
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// Server is the kratos server middleware which installs the request logger,
+// get it by FromContext in the handler.
+func Server(logger log.Logger, opts ...ContextOption) middleware.Middleware {
+	o := newContextOptions(opts)
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			if RequestID(ctx) == "" {
+				var id string
+				if tr, ok := transport.FromServerContext(ctx); ok {
+					if id = tr.RequestHeader().Get(o.header); id == "" {
+						id = uuid.New().String()
+					}
+					tr.ReplyHeader().Set(o.header, id)
+				} else {
+					id = uuid.New().String()
+				}
+				ctx = context.WithValue(ctx, requestIDKey{}, id)
+			}
+			return handler(NewContext(ctx, WithContext(ctx, logger, opts...)), req)
+		}
+	}
+}
+
+// Gin is the gin middleware which installs the request logger into the
+// context of the request, the operation is the route path.
+func Gin(logger log.Logger, opts ...ContextOption) gin.HandlerFunc {
+	o := newContextOptions(opts)
+	return func(c *gin.Context) {
+		id := c.GetHeader(o.header)
+		if id == "" {
+			id = uuid.New().String()
+		}
+		c.Header(o.header, id)
+
+		ctx := context.WithValue(c.Request.Context(), requestIDKey{}, id)
+		l := WithContext(ctx, logger, opts...)
+		var keyvals []interface{}
+		if _, ok := transport.FromServerContext(ctx); !ok && c.FullPath() != "" {
+			keyvals = append(keyvals, FieldOperation, c.FullPath())
+		}
+		if _, ok := http.RequestFromServerContext(ctx); !ok && c.Request.RemoteAddr != "" {
+			keyvals = append(keyvals, FieldRemoteAddr, c.Request.RemoteAddr)
+		}
+		if len(keyvals) > 0 {
+			l = log.With(l, keyvals...)
+		}
+		c.Request = c.Request.WithContext(NewContext(ctx, l))
+		c.Next()
+	}
+}
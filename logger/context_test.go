@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string        { return http.Header(hc).Get(key) }
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range hc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type testTransport struct {
+	reqHeader   headerCarrier
+	replyHeader headerCarrier
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return "/helloworld.Greeter/SayHello" }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *testTransport) ReplyHeader() transport.Header   { return tr.replyHeader }
+
+type tenantKey struct{}
+
+func TestServer(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zapLog.NewLogger(zap.New(core))
+
+	tr := &testTransport{reqHeader: headerCarrier{}, replyHeader: headerCarrier{}}
+	tr.reqHeader.Set(RequestIDHeader, "req-1")
+	ctx := transport.NewServerContext(context.WithValue(context.Background(), tenantKey{}, "acme"), tr)
+
+	handler := Server(logger, WithContextKey("tenant", tenantKey{}))(func(ctx context.Context, req interface{}) (interface{}, error) {
+		l, ok := FromContext(ctx)
+		assert.True(t, ok)
+		log.NewHelper(l).Info("hello")
+		return nil, nil
+	})
+	_, err := handler(ctx, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "req-1", tr.replyHeader.Get(RequestIDHeader))
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "req-1", fields[FieldRequestID])
+		assert.Equal(t, "/helloworld.Greeter/SayHello", fields[FieldOperation])
+		assert.Equal(t, "acme", fields["tenant"])
+	}
+}
+
+func TestGin(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zapLog.NewLogger(zap.New(core))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Gin(logger))
+	router.GET("/hello/:name", func(c *gin.Context) {
+		l, _ := FromContext(c.Request.Context())
+		log.NewHelper(l).Info("hello")
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hello/tom", nil))
+	requestID := w.Header().Get(RequestIDHeader)
+	assert.NotEmpty(t, requestID)
+
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, requestID, fields[FieldRequestID])
+		assert.Equal(t, "/hello/:name", fields[FieldOperation])
+		assert.Equal(t, "192.0.2.1:1234", fields[FieldRemoteAddr])
+	}
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+)
+
+// LevelPath is the path of the level handler.
+const LevelPath = "/log/level"
+
+// DefaultLevelTTL is the ttl of the level set by the handler.
+const DefaultLevelTTL = 10 * time.Minute
+
+// LevelRequest is the request to set the level of the module, the empty
+// module is the root.
+type LevelRequest struct {
+	Module string `json:"module"`
+	Level  string `json:"level"`
+	// TTL is the duration like 5m, default is DefaultLevelTTL
+	TTL string `json:"ttl"`
+}
+
+// LevelResponse is the level of the root and the modules.
+type LevelResponse struct {
+	Level   string            `json:"level"`
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+// LevelHandler returns the handler of the levels. GET returns the levels, PUT
+// sets the level of the LevelRequest which is reverted after the ttl, DELETE
+// reverts the level of the module query at once.
+func LevelHandler(levels *zapLog.Levels) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req LevelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid level request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			var level zapcore.Level
+			if err := level.UnmarshalText([]byte(req.Level)); err != nil || req.Level == "" {
+				http.Error(w, "invalid level "+req.Level, http.StatusBadRequest)
+				return
+			}
+			ttl := DefaultLevelTTL
+			if req.TTL != "" {
+				var err error
+				if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+					http.Error(w, "invalid ttl "+req.TTL, http.StatusBadRequest)
+					return
+				}
+			}
+			levels.Override(req.Module, level, ttl)
+		case http.MethodDelete:
+			levels.Revert(r.URL.Query().Get("module"))
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resp := LevelResponse{Modules: make(map[string]string)}
+		for module, level := range levels.Modules() {
+			if module == "" {
+				resp.Level = level.String()
+			} else {
+				resp.Modules[module] = level.String()
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
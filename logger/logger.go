@@ -1,32 +1,249 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"time"
 
+	"github.com/liuxiong332/kratos-starter/logger/sink"
 	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+	"github.com/liuxiong332/kratos-starter/secret"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	lumberjack "gopkg.in/natefinch/lumberjack.v2"
 )
 
+// NewLogger creates the logger of the DefaultConfig.
 func NewLogger() *zapLog.Logger {
-	fileOut := zapcore.AddSync(&lumberjack.Logger{
-		Filename:   "./logs/elk.log",
-		MaxSize:    10, // megabytes
-		MaxBackups: 3,
-		MaxAge:     3, // days
-	})
-	stdout := zapcore.AddSync(os.Stdout)
-	w := zap.CombineWriteSyncers(stdout, fileOut)
+	logger, err := NewLoggerWithConfig(DefaultConfig())
+	if err != nil {
+		panic(err)
+	}
+	return logger
+}
+
+// NewLoggerWithConfig creates the logger writing to the outputs of the config,
+// the files of the outputs are closed with the logger.
+func NewLoggerWithConfig(c *LoggerConfig) (*zapLog.Logger, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	level, _ := parseLevel(c.Level)
+	modules, _ := c.moduleLevels()
+	stacktraceLevel := zapcore.ErrorLevel
+	if c.StacktraceLevel != "" {
+		stacktraceLevel, _ = parseLevel(c.StacktraceLevel)
+	}
+
+	masker := c.Masker
+	if masker == nil {
+		masker = secret.Default()
+	}
+	cores, closers, err := newCores(c.Outputs, func(output OutputConfig) zapcore.Encoder {
+		return outputEncoder(output, masker)
+	}, masker.Redact)
+	if err != nil {
+		return nil, err
+	}
+
+	core := zapcore.NewTee(cores...)
+	if c.Sampling != nil {
+		core = newSampler(core, c.Sampling)
+	}
+	if c.RateLimit != nil {
+		rateLimitCore := newRateLimitCore(core, c.RateLimit)
+		// the last summaries are written before the outputs are closed
+		closers = append([]io.Closer{rateLimitCore}, closers...)
+		core = rateLimitCore
+	}
+	levels := zapLog.NewLevels(level)
+	levels.SetLevels(level, modules)
+	core = zapLog.NewLevelCore(core, levels)
+
+	zapOpts := []zap.Option{
+		zap.ErrorOutput(zapcore.AddSync(os.Stderr)),
+		zap.AddStacktrace(stacktraceLevel),
+	}
+	if !c.DisableCaller {
+		zapOpts = append(zapOpts, zap.AddCaller(), zap.AddCallerSkip(c.CallerSkip))
+	}
+	if len(c.Fields) > 0 {
+		fields := make([]zap.Field, 0, len(c.Fields))
+		for k, v := range c.Fields {
+			fields = append(fields, zap.Any(k, v))
+		}
+		zapOpts = append(zapOpts, zap.Fields(fields...))
+	}
+
+	loggerOpts := []zapLog.Option{zapLog.WithLevels(levels), zapLog.WithCallerSkip(c.CallerSkip)}
+	for _, closer := range closers {
+		loggerOpts = append(loggerOpts, zapLog.WithCloser(closer))
+	}
+	return zapLog.NewLogger(zap.New(core, zapOpts...), loggerOpts...), nil
+}
+
+// NewCore creates the core writing to the outputs by the json encoder of the
+// encoder config, for the logs of their own schema like the audit log. The
+// values are not masked. The closer closes the files and sinks.
+func NewCore(outputs []OutputConfig, encoderConfig zapcore.EncoderConfig) (zapcore.Core, io.Closer, error) {
+	if err := (&LoggerConfig{Outputs: outputs}).validate(); err != nil {
+		return nil, nil, err
+	}
+	cores, closers, err := newCores(outputs, func(OutputConfig) zapcore.Encoder {
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return zapcore.NewTee(cores...), multiCloser(closers), nil
+}
+
+type multiCloser []io.Closer
+
+func (closers multiCloser) Close() error {
+	var err error
+	for _, closer := range closers {
+		if closeErr := closer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// newCores creates the cores of the outputs, the files and sinks are closed by
+// the closers. The redact masks the messages and stacks passed to the sinks
+// out of the encoder, nil if not masked.
+func newCores(outputs []OutputConfig, encoder func(OutputConfig) zapcore.Encoder, redact func(string) string) ([]zapcore.Core, []io.Closer, error) {
+	var (
+		cores   []zapcore.Core
+		closers []io.Closer
+	)
+	for _, output := range outputs {
+		// the logger level is checked by the levels core
+		outputLevel := zapcore.DebugLevel
+		if output.Level != "" {
+			outputLevel, _ = parseLevel(output.Level)
+		}
 
+		var w zapcore.WriteSyncer
+		switch output.outputType() {
+		case OutputStdout:
+			w = zapcore.AddSync(os.Stdout)
+		case OutputStderr:
+			w = zapcore.AddSync(os.Stderr)
+		case OutputFile:
+			fileLogger := newFileLogger(output)
+			closers = append(closers, fileLogger)
+			w = zapcore.AddSync(fileLogger)
+		default:
+			s, err := newSink(output)
+			if err != nil {
+				_ = multiCloser(closers).Close()
+				return nil, nil, err
+			}
+			closers = append(closers, s)
+			var sinkOpts []sink.CoreOption
+			if redact != nil {
+				sinkOpts = append(sinkOpts, sink.WithRedact(redact))
+			}
+			cores = append(cores, sink.NewCore(encoder(output), s, outputLevel, sinkOpts...))
+			continue
+		}
+		cores = append(cores, zapcore.NewCore(encoder(output), w, outputLevel))
+	}
+	return cores, closers, nil
+}
+
+func newSink(output OutputConfig) (sink.Sink, error) {
+	switch output.outputType() {
+	case OutputSyslog:
+		return sink.NewSyslog(*output.Syslog)
+	case OutputHTTP:
+		return sink.NewHTTP(*output.HTTP)
+	default:
+		return sink.NewGELF(*output.GELF)
+	}
+}
+
+// outputEncoder returns the encoder of the output redacted by the masker, the
+// log servers index the json logs.
+func outputEncoder(output OutputConfig, masker *secret.Masker) zapcore.Encoder {
+	switch output.outputType() {
+	case OutputHTTP:
+		return newEncoder(EncoderJSON, masker)
+	case OutputGELF:
+		return secret.NewZapEncoder(zapcore.NewJSONEncoder(sink.GELFEncoderConfig()), masker)
+	default:
+		return newEncoder(output.Encoder, masker)
+	}
+}
+
+func newEncoder(name string, masker *secret.Masker) zapcore.Encoder {
 	config := zap.NewProductionEncoderConfig()
 	config.MessageKey = "message"
 	config.EncodeTime = zapcore.ISO8601TimeEncoder
 
-	core := zapcore.NewCore(zapcore.NewJSONEncoder(config), w, zap.InfoLevel)
+	var encoder zapcore.Encoder
+	if name == EncoderConsole {
+		encoder = zapcore.NewConsoleEncoder(config)
+	} else {
+		encoder = zapcore.NewJSONEncoder(config)
+	}
+	// 日志中的敏感信息会被替换
+	return secret.NewZapEncoder(encoder, masker)
+}
+
+func newFileLogger(output OutputConfig) *lumberjack.Logger {
+	fileLogger := &lumberjack.Logger{Filename: output.Path}
+	if r := output.Rotation; r != nil {
+		fileLogger.MaxSize = r.MaxSize
+		fileLogger.MaxBackups = r.MaxBackups
+		fileLogger.MaxAge = r.MaxAge
+		fileLogger.Compress = r.Compress
+		fileLogger.LocalTime = r.LocalTime
+	}
+	return fileLogger
+}
 
-	zlog := zap.New(core, zap.ErrorOutput(zapcore.AddSync(os.Stderr)), zap.AddCaller(), zap.AddCallerSkip(3), zap.AddStacktrace(zap.ErrorLevel))
+// newSampler samples the logs like the zap production config by default.
+func newSampler(core zapcore.Core, c *SamplingConfig) zapcore.Core {
+	tick := time.Second
+	if c.Tick != "" {
+		tick, _ = time.ParseDuration(c.Tick)
+	}
+	initial, thereafter := c.Initial, c.Thereafter
+	if initial <= 0 {
+		initial = 100
+	}
+	if thereafter <= 0 {
+		thereafter = 100
+	}
+	return zapcore.NewSamplerWithOptions(core, tick, initial, thereafter)
+}
+
+func newRateLimitCore(core zapcore.Core, c *RateLimitConfig) *zapLog.RateLimitCore {
+	interval := 10 * time.Second
+	if c.SummaryInterval != "" {
+		interval, _ = time.ParseDuration(c.SummaryInterval)
+	}
+	limits := map[string]zapLog.RateLimit{"": {Rate: c.Rate, Burst: c.Burst}}
+	for module, limit := range c.Modules {
+		if module != "" {
+			limits[module] = limit
+		}
+	}
+	return zapLog.NewRateLimitCore(core, limits, interval)
+}
 
-	return zapLog.NewLogger(zlog)
+func parseLevel(s string) (zapcore.Level, error) {
+	if s == "" {
+		return zapcore.InfoLevel, nil
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("log level: %w", err)
+	}
+	return level, nil
 }
@@ -1,12 +1,130 @@
 package logger
 
 import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-kratos/kratos/v2/config"
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/liuxiong332/kratos-starter/config/memory"
+	"github.com/liuxiong332/kratos-starter/logger/sink"
+	zapLog "github.com/liuxiong332/kratos-starter/logger/zap"
+	"github.com/liuxiong332/kratos-starter/secret"
 )
 
 func TestLogger(t *testing.T) {
+	// the default config writes ./logs/elk.log
+	wd, _ := os.Getwd()
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	defer os.Chdir(wd)
+
 	logger := NewLogger()
 	logger.Log(log.LevelInfo, "hello", "Hello world")
+	data, err := os.ReadFile(filepath.Join("logs", "elk.log"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "Hello world")
+}
+
+func TestLoggerWithConfig(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewLoggerWithConfig(&LoggerConfig{
+		Level: "debug",
+		Outputs: []OutputConfig{
+			{Path: filepath.Join(dir, "all.log")},
+			{Path: filepath.Join(dir, "warn.log"), Encoder: EncoderConsole, Level: "warn"},
+		},
+		Fields: map[string]interface{}{"service": "test"},
+	})
+	assert.NoError(t, err)
+	_ = logger.Log(log.LevelDebug, "msg", "debug message")
+	_ = logger.Log(log.LevelWarn, "msg", "warn message")
+	assert.NoError(t, logger.Close())
+
+	all, _ := os.ReadFile(filepath.Join(dir, "all.log"))
+	assert.Contains(t, string(all), `"message":"debug message"`)
+	assert.Contains(t, string(all), `"service":"test"`)
+	warn, _ := os.ReadFile(filepath.Join(dir, "warn.log"))
+	assert.NotContains(t, string(warn), "debug message")
+	assert.Contains(t, string(warn), "\twarn\t")
+}
+
+func TestParseLoggerConfig(t *testing.T) {
+	c := config.New(config.WithSource(memory.New(map[string]interface{}{
+		"log.level":   "warn",
+		"log.outputs": []interface{}{map[string]interface{}{"type": "stderr", "encoder": "console"}},
+	})))
+	assert.NoError(t, c.Load())
+	loggerConfig, err := ParseLoggerConfig(c)
+	assert.NoError(t, err)
+	assert.Equal(t, "warn", loggerConfig.Level)
+	assert.Equal(t, []string{OutputStderr}, loggerConfig.OutputNames())
+	assert.Equal(t, 3, loggerConfig.CallerSkip)
+
+	for _, output := range []map[string]interface{}{{"type": "file"}, {"type": "syslog"}} {
+		c = config.New(config.WithSource(memory.New(map[string]interface{}{
+			"log.outputs": []interface{}{output},
+		})))
+		assert.NoError(t, c.Load())
+		_, err = ParseLoggerConfig(c)
+		assert.Error(t, err)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	levels := zapLog.NewLevels(zapcore.InfoLevel)
+	handler := LevelHandler(levels)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, LevelPath, strings.NewReader(`{"module":"consul","level":"debug","ttl":"1m"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp LevelResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, LevelResponse{Level: "info", Modules: map[string]string{"consul": "debug"}}, resp)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, LevelPath, strings.NewReader(`{"level":"verbose"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, LevelPath+"?module=consul", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, zapcore.InfoLevel, levels.Level("consul"))
+}
+
+func TestLoggerSinkMasked(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	masker := secret.NewMasker()
+	masker.MarkValue("s3cr3t-value")
+	logger, err := NewLoggerWithConfig(&LoggerConfig{
+		Level: "info",
+		Outputs: []OutputConfig{{
+			Type: OutputGELF,
+			GELF: &sink.GELFConfig{Network: "udp", Address: conn.LocalAddr().String()},
+		}},
+		Masker: masker,
+	})
+	assert.NoError(t, err)
+	defer logger.Close()
+	log.NewHelper(logger).Infof("connect with %s", "s3cr3t-value")
+
+	// the GELF short_message is taken from the entry message
+	buf := make([]byte, 8192)
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(buf[:n]), "s3cr3t-value")
+	assert.Contains(t, string(buf[:n]), `"short_message":"connect with ******"`)
 }
@@ -0,0 +1,220 @@
+package sink
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/liuxiong332/kratos-starter/metrics"
+)
+
+const (
+	gelfChunkSize  = 8192
+	gelfChunkData  = gelfChunkSize - 12
+	gelfChunkLimit = 128
+)
+
+var gelfInvalidKey = regexp.MustCompile(`[^\w.\-]`)
+
+// GELFConfig is the config of the GELF sink.
+type GELFConfig struct {
+	// Network is udp or tcp, the udp messages are chunked if too large
+	Network string `json:"network"`
+	Address string `json:"address"`
+	// Host defaults to the os hostname
+	Host string `json:"host"`
+	// Timeout is the dial and write timeout, default is 5s
+	Timeout string `json:"timeout"`
+}
+
+// GELFEncoderConfig returns the json encoder config of the GELF sink, the
+// message, level, time and stack trace are taken from the entry.
+func GELFEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+		EncodeName:     zapcore.FullNameEncoder,
+	}
+}
+
+// GELF writes the entries as the GELF 1.1 messages, the line of the entry is
+// the json object of the fields encoded by GELFEncoderConfig. The connection
+// is redialed like Syslog.
+type GELF struct {
+	config  GELFConfig
+	timeout time.Duration
+	host    string
+
+	lock    sync.Mutex
+	conn    net.Conn
+	backoff redialBackoff
+}
+
+// NewGELF creates the GELF sink, the connection is dialed by the first write.
+func NewGELF(c GELFConfig) (*GELF, error) {
+	switch c.Network {
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("unknown gelf network %s", c.Network)
+	}
+	if c.Address == "" {
+		return nil, fmt.Errorf("gelf address is required")
+	}
+	timeout, err := parseDuration(c.Timeout, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("gelf timeout: %w", err)
+	}
+	host := c.Host
+	if host == "" {
+		if host, _ = os.Hostname(); host == "" {
+			host = "unknown"
+		}
+	}
+	return &GELF{config: c, timeout: timeout, host: host, backoff: newRedialBackoff()}, nil
+}
+
+// Format returns the GELF message of the entry.
+func (s *GELF) Format(ent zapcore.Entry, line []byte) ([]byte, error) {
+	fields := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("decode gelf fields: %w", err)
+	}
+
+	msg := map[string]interface{}{
+		"version":       "1.1",
+		"host":          s.host,
+		"short_message": ent.Message,
+		"timestamp":     float64(ent.Time.UnixNano()/int64(time.Millisecond)) / 1000,
+		"level":         severity(ent.Level),
+	}
+	if ent.Stack != "" {
+		msg["full_message"] = ent.Stack
+	}
+	for k, v := range fields {
+		key := "_" + gelfInvalidKey.ReplaceAllString(k, "_")
+		if key == "_id" {
+			// _id is reserved by GELF
+			key = "_id_"
+		}
+		switch v.(type) {
+		case string, json.Number:
+			msg[key] = v
+		case bool:
+			msg[key] = fmt.Sprint(v)
+		default:
+			// the additional fields are strings or numbers
+			data, _ := json.Marshal(v)
+			msg[key] = string(data)
+		}
+	}
+	return json.Marshal(msg)
+}
+
+func (s *GELF) WriteEntry(ent zapcore.Entry, line []byte) error {
+	msg, err := s.Format(ent, line)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	if s.conn == nil && !s.backoff.allow(now) {
+		// drop the entry until the next redial
+		metrics.Default().LogDropped("gelf")
+		return nil
+	}
+	err = s.write(msg)
+	if err != nil && s.conn != nil {
+		// redial once, the server may be restarted
+		s.closeConn()
+		err = s.write(msg)
+	}
+	if err != nil {
+		s.closeConn()
+		s.backoff.fail(now)
+		metrics.Default().LogDropped("gelf")
+		return err
+	}
+	s.backoff.reset()
+	return nil
+}
+
+func (s *GELF) write(msg []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.config.Network, s.config.Address, s.timeout)
+		if err != nil {
+			return fmt.Errorf("dial gelf %s: %w", s.config.Address, err)
+		}
+		s.conn = conn
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if s.config.Network == "tcp" {
+		// the tcp messages are delimited by the null byte
+		_, err := s.conn.Write(append(msg, 0))
+		return err
+	}
+	if len(msg) <= gelfChunkSize {
+		_, err := s.conn.Write(msg)
+		return err
+	}
+	return s.writeChunks(msg)
+}
+
+func (s *GELF) writeChunks(msg []byte) error {
+	count := (len(msg) + gelfChunkData - 1) / gelfChunkData
+	if count > gelfChunkLimit {
+		return fmt.Errorf("gelf message of %d bytes is too large", len(msg))
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, gelfChunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * gelfChunkData
+		if end > len(msg) {
+			end = len(msg)
+		}
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*gelfChunkData:end]...)
+		if _, err := s.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GELF) closeConn() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *GELF) Sync() error {
+	return nil
+}
+
+func (s *GELF) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closeConn()
+	return nil
+}
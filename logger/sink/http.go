@@ -0,0 +1,312 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/liuxiong332/kratos-starter/metrics"
+)
+
+// Formats of the HTTP sink
+const (
+	FormatElasticsearch = "elasticsearch"
+	FormatLoki          = "loki"
+)
+
+// HTTPConfig is the config of the async HTTP sink.
+type HTTPConfig struct {
+	// URL is the Elasticsearch bulk API like http://localhost:9200/_bulk, or
+	// the Loki push API like http://localhost:3100/loki/api/v1/push
+	URL string `json:"url"`
+	// Format is elasticsearch or loki
+	Format string `json:"format"`
+	// Index is the Elasticsearch index, the index of the URL like
+	// http://localhost:9200/logs/_bulk is used if empty
+	Index string `json:"index"`
+	// Labels are the Loki stream labels
+	Labels  map[string]string `json:"labels"`
+	Headers map[string]string `json:"headers"`
+	// BatchSize is the max entries of one request, default is 100
+	BatchSize int `json:"batch_size"`
+	// QueueSize is the max entries waiting to be sent, default is 1000
+	QueueSize int `json:"queue_size"`
+	// FlushInterval is the max delay of the entries, default is 1s
+	FlushInterval string `json:"flush_interval"`
+	// Timeout is the request timeout, default is 5s
+	Timeout string `json:"timeout"`
+	// Block blocks the log call when the queue is full, the entries are
+	// dropped by default
+	Block bool `json:"block"`
+}
+
+// HTTPStats is the counters of the HTTP sink.
+type HTTPStats struct {
+	// Sent is the entries sent
+	Sent int64 `json:"sent"`
+	// Dropped is the entries dropped by the full queue
+	Dropped int64 `json:"dropped"`
+	// Failed is the entries of the failed requests, and the entries rejected
+	// by the Elasticsearch bulk API
+	Failed int64 `json:"failed"`
+}
+
+type httpEntry struct {
+	time time.Time
+	line []byte
+}
+
+// HTTP sends the entries in batches by the background goroutine. The entries
+// are queued without waiting for the requests, the full queue drops the
+// entries or blocks the log call if configured.
+type HTTP struct {
+	config    HTTPConfig
+	client    *http.Client
+	interval  time.Duration
+	queue     chan httpEntry
+	flushReqs chan chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	sent    atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewHTTP creates the HTTP sink and starts its background goroutine.
+func NewHTTP(c HTTPConfig) (*HTTP, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("http sink url is required")
+	}
+	switch c.Format {
+	case FormatElasticsearch, FormatLoki:
+	default:
+		return nil, fmt.Errorf("unknown http sink format %s", c.Format)
+	}
+	interval, err := parseDuration(c.FlushInterval, time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("http sink flush interval: %w", err)
+	}
+	timeout, err := parseDuration(c.Timeout, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("http sink timeout: %w", err)
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 1000
+	}
+
+	s := &HTTP{
+		config:    c,
+		client:    &http.Client{Timeout: timeout},
+		interval:  interval,
+		queue:     make(chan httpEntry, c.QueueSize),
+		flushReqs: make(chan chan struct{}),
+		done:      make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *HTTP) WriteEntry(ent zapcore.Entry, line []byte) error {
+	entry := httpEntry{time: ent.Time, line: append([]byte(nil), trimNewline(line)...)}
+	select {
+	case <-s.closed:
+		return fmt.Errorf("http sink is closed")
+	default:
+	}
+	if s.config.Block {
+		select {
+		case s.queue <- entry:
+		case <-s.closed:
+			return fmt.Errorf("http sink is closed")
+		}
+		return nil
+	}
+	select {
+	case s.queue <- entry:
+	default:
+		s.dropped.Add(1)
+		metrics.Default().LogDropped(s.config.Format)
+	}
+	return nil
+}
+
+// Stats returns the counters of the sink.
+func (s *HTTP) Stats() HTTPStats {
+	return HTTPStats{Sent: s.sent.Load(), Dropped: s.dropped.Load(), Failed: s.failed.Load()}
+}
+
+func (s *HTTP) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	batch := make([]httpEntry, 0, s.config.BatchSize)
+	send := func() {
+		if len(batch) > 0 {
+			s.send(batch)
+			batch = batch[:0]
+		}
+	}
+	// drain sends the queued entries
+	drain := func() {
+		for {
+			select {
+			case entry := <-s.queue:
+				if batch = append(batch, entry); len(batch) >= s.config.BatchSize {
+					send()
+				}
+			default:
+				send()
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case entry := <-s.queue:
+			if batch = append(batch, entry); len(batch) >= s.config.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case flushed := <-s.flushReqs:
+			drain()
+			close(flushed)
+		case <-s.closed:
+			drain()
+			return
+		}
+	}
+}
+
+func (s *HTTP) send(batch []httpEntry) {
+	var failed int
+	body, contentType, err := s.encode(batch)
+	if err == nil {
+		failed, err = s.post(body, contentType)
+	}
+	if err != nil {
+		failed = len(batch)
+	}
+	if failed > 0 {
+		s.failed.Add(int64(failed))
+		metrics.Default().LogFailed(s.config.Format, failed)
+	}
+	s.sent.Add(int64(len(batch) - failed))
+}
+
+func (s *HTTP) encode(batch []httpEntry) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if s.config.Format == FormatElasticsearch {
+		meta := map[string]string{}
+		if s.config.Index != "" {
+			meta["_index"] = s.config.Index
+		}
+		action, err := json.Marshal(map[string]interface{}{"index": meta})
+		if err != nil {
+			return nil, "", err
+		}
+		for _, entry := range batch {
+			buf.Write(action)
+			buf.WriteByte('\n')
+			buf.Write(entry.line)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), "application/x-ndjson", nil
+	}
+
+	values := make([][2]string, 0, len(batch))
+	for _, entry := range batch {
+		values = append(values, [2]string{strconv.FormatInt(entry.time.UnixNano(), 10), string(entry.line)})
+	}
+	labels := s.config.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"streams": []interface{}{map[string]interface{}{"stream": labels, "values": values}},
+	})
+	return body, "application/json", err
+}
+
+// bulkResponse is the response of the Elasticsearch bulk API.
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+	} `json:"items"`
+}
+
+// post sends the body and returns the entries rejected by the Elasticsearch
+// bulk API.
+func (s *HTTP) post(body []byte, contentType string) (int, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, fmt.Errorf("http sink status %d", resp.StatusCode)
+	}
+	if s.config.Format != FormatElasticsearch {
+		return 0, nil
+	}
+	// the bulk API responds 200 even if some documents are rejected
+	var bulk bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bulk); err != nil || !bulk.Errors {
+		return 0, nil
+	}
+	var failed int
+	for _, item := range bulk.Items {
+		for _, result := range item {
+			if result.Status < 200 || result.Status > 299 {
+				failed++
+			}
+		}
+	}
+	return failed, nil
+}
+
+// Sync sends the queued entries and waits for the requests.
+func (s *HTTP) Sync() error {
+	flushed := make(chan struct{})
+	select {
+	case s.flushReqs <- flushed:
+		<-flushed
+	case <-s.done:
+	}
+	return nil
+}
+
+// Close sends the queued entries and stops the background goroutine.
+func (s *HTTP) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	<-s.done
+	return nil
+}
@@ -0,0 +1,151 @@
+// Package sink ships the logs to the log servers, like syslog, the
+// Elasticsearch bulk or Loki push API, and GELF.
+package sink
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// The redial delays of the syslog and GELF sinks after the connection failed
+const (
+	minRedialDelay = time.Second
+	maxRedialDelay = time.Minute
+)
+
+// Sink receives the encoded log entries.
+type Sink interface {
+	// WriteEntry writes the encoded line of the entry, the line is reused
+	// after the call returns.
+	WriteEntry(ent zapcore.Entry, line []byte) error
+	Sync() error
+	Close() error
+}
+
+// CoreOption is the option of the sink core.
+type CoreOption func(c *core)
+
+// WithRedact redacts the message and the stack of the entries passed to the
+// sink, like secret.Masker.Redact. The sinks like GELF take them from the
+// entry instead of the encoded line.
+func WithRedact(redact func(string) string) CoreOption {
+	return func(c *core) {
+		c.redact = redact
+	}
+}
+
+// NewCore returns the core which writes the entries encoded by the encoder to
+// the sink.
+func NewCore(encoder zapcore.Encoder, sink Sink, enabler zapcore.LevelEnabler, opts ...CoreOption) zapcore.Core {
+	c := &core{LevelEnabler: enabler, encoder: encoder, sink: sink}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type core struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	sink    Sink
+	redact  func(string) string
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	encoder := c.encoder.Clone()
+	for _, f := range fields {
+		f.AddTo(encoder)
+	}
+	return &core{LevelEnabler: c.LevelEnabler, encoder: encoder, sink: c.sink, redact: c.redact}
+}
+
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	if c.redact != nil {
+		ent.Message = c.redact(ent.Message)
+		ent.Stack = c.redact(ent.Stack)
+	}
+	err = c.sink.WriteEntry(ent, buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		// the process may exit after the fatal log
+		return c.sink.Sync()
+	}
+	return nil
+}
+
+func (c *core) Sync() error {
+	return c.sink.Sync()
+}
+
+// severity returns the syslog severity of the level, which is the level of
+// GELF too.
+func severity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	default:
+		return 2
+	}
+}
+
+func trimNewline(line []byte) []byte {
+	for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// redialBackoff delays the redial after the dial or write failed, the delay is
+// doubled by every failure up to the max and reset by the success. The entries
+// are dropped before the next redial, so the log calls are not blocked by the
+// down server.
+type redialBackoff struct {
+	min, max time.Duration
+	delay    time.Duration
+	next     time.Time
+}
+
+func newRedialBackoff() redialBackoff {
+	return redialBackoff{min: minRedialDelay, max: maxRedialDelay}
+}
+
+// allow returns whether the connection can be redialed now.
+func (b *redialBackoff) allow(now time.Time) bool {
+	return !now.Before(b.next)
+}
+
+func (b *redialBackoff) fail(now time.Time) {
+	if b.delay *= 2; b.delay < b.min {
+		b.delay = b.min
+	}
+	if b.delay > b.max {
+		b.delay = b.max
+	}
+	b.next = now.Add(b.delay)
+}
+
+func (b *redialBackoff) reset() {
+	b.delay = 0
+	b.next = time.Time{}
+}
@@ -0,0 +1,310 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/liuxiong332/kratos-starter/metrics"
+)
+
+func newTestLogger(s Sink, encoderConfig zapcore.EncoderConfig) *zap.Logger {
+	return zap.New(NewCore(zapcore.NewJSONEncoder(encoderConfig), s, zapcore.DebugLevel))
+}
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	s, err := NewSyslog(SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), AppName: "test", Hostname: "host"})
+	assert.NoError(t, err)
+	defer s.Close()
+	newTestLogger(s, zap.NewProductionEncoderConfig()).Named("consul").Warn("hello", zap.String("user", "tom"))
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	msg := string(buf[:n])
+	// facility user, severity warning
+	assert.True(t, strings.HasPrefix(msg, "<12>1 "), msg)
+	assert.Contains(t, msg, " host test ")
+	assert.Contains(t, msg, " consul - {")
+	assert.Contains(t, msg, `"user":"tom"`)
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// the frame is the octet count, the space and the message
+		r := bufio.NewReader(conn)
+		count, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(count))
+		msg := make([]byte, n)
+		_, _ = io.ReadFull(r, msg)
+		received <- string(msg)
+	}()
+
+	s, err := NewSyslog(SyslogConfig{Network: "tcp", Address: ln.Addr().String()})
+	assert.NoError(t, err)
+	defer s.Close()
+	newTestLogger(s, zap.NewProductionEncoderConfig()).Info("hello")
+
+	select {
+	case msg := <-received:
+		assert.True(t, strings.HasPrefix(msg, "<14>1 "), msg)
+		assert.True(t, strings.HasSuffix(msg, "}"), msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("syslog message is not received")
+	}
+}
+
+// metricsText returns the metrics exposed by the handler.
+func metricsText(m *metrics.Metrics) string {
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", metrics.Path, nil))
+	return w.Body.String()
+}
+
+func TestSyslogRedialBackoff(t *testing.T) {
+	m := metrics.New(metrics.WithNamespace("test"))
+	metrics.Enable(m)
+	defer metrics.Enable(nil)
+
+	// the address is not listened
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	assert.NoError(t, ln.Close())
+
+	s, err := NewSyslog(SyslogConfig{Network: "tcp", Address: addr})
+	assert.NoError(t, err)
+	defer s.Close()
+	ent := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "hello"}
+	assert.Error(t, s.WriteEntry(ent, []byte("{}")))
+	// the entries are dropped without dialing before the next redial
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, s.WriteEntry(ent, []byte("{}")))
+	}
+	assert.Contains(t, metricsText(m), `test_log_dropped_total{sink="syslog"} 4`)
+
+	ln, err = net.Listen("tcp", addr)
+	assert.NoError(t, err)
+	defer ln.Close()
+	accepted := make(chan struct{})
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			close(accepted)
+			_ = conn.Close()
+		}
+	}()
+	s.lock.Lock()
+	s.backoff.next = time.Now()
+	s.lock.Unlock()
+	assert.NoError(t, s.WriteEntry(ent, []byte("{}")))
+	select {
+	case <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("syslog is not redialed")
+	}
+}
+
+func TestRedialBackoff(t *testing.T) {
+	b := newRedialBackoff()
+	now := time.Now()
+	assert.True(t, b.allow(now))
+	b.fail(now)
+	assert.False(t, b.allow(now))
+	assert.True(t, b.allow(now.Add(minRedialDelay)))
+	for i := 0; i < 10; i++ {
+		b.fail(now)
+	}
+	assert.Equal(t, maxRedialDelay, b.delay)
+	b.reset()
+	assert.True(t, b.allow(now))
+}
+
+func TestHTTPElasticsearch(t *testing.T) {
+	var (
+		lock   sync.Mutex
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lock.Lock()
+		bodies = append(bodies, string(body))
+		lock.Unlock()
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	s, err := NewHTTP(HTTPConfig{URL: server.URL + "/_bulk", Format: FormatElasticsearch, Index: "logs", BatchSize: 2})
+	assert.NoError(t, err)
+	logger := newTestLogger(s, zap.NewProductionEncoderConfig())
+	for i := 0; i < 3; i++ {
+		logger.Info("hello", zap.Int("i", i))
+	}
+	assert.NoError(t, logger.Sync())
+	assert.NoError(t, s.Close())
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Len(t, bodies, 2)
+	lines := strings.Split(strings.TrimSpace(bodies[0]), "\n")
+	if assert.Len(t, lines, 4) {
+		assert.Equal(t, `{"index":{"_index":"logs"}}`, lines[0])
+		assert.Contains(t, lines[1], `"i":0`)
+	}
+	assert.Equal(t, HTTPStats{Sent: 3}, s.Stats())
+}
+
+func TestHTTPElasticsearchRejected(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`))
+	}))
+	defer server.Close()
+
+	s, err := NewHTTP(HTTPConfig{URL: server.URL + "/logs/_bulk", Format: FormatElasticsearch})
+	assert.NoError(t, err)
+	logger := newTestLogger(s, zap.NewProductionEncoderConfig())
+	logger.Info("hello")
+	logger.Info("world")
+	assert.NoError(t, s.Close())
+
+	// the index of the url is used
+	assert.Equal(t, `{"index":{}}`, strings.SplitN(body, "\n", 2)[0])
+	assert.Equal(t, HTTPStats{Sent: 1, Failed: 1}, s.Stats())
+}
+
+func TestHTTPLoki(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var push map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&push)
+		received <- push
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	s, err := NewHTTP(HTTPConfig{URL: server.URL, Format: FormatLoki, Labels: map[string]string{"app": "test"}})
+	assert.NoError(t, err)
+	defer s.Close()
+	logger := newTestLogger(s, zap.NewProductionEncoderConfig())
+	logger.Info("hello")
+	assert.NoError(t, logger.Sync())
+
+	push := <-received
+	stream := push["streams"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"app": "test"}, stream["stream"])
+	value := stream["values"].([]interface{})[0].([]interface{})
+	assert.Contains(t, value[1], `"msg":"hello"`)
+}
+
+func TestHTTPDropped(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	s, err := NewHTTP(HTTPConfig{URL: server.URL, Format: FormatLoki, BatchSize: 1, QueueSize: 1})
+	assert.NoError(t, err)
+	logger := newTestLogger(s, zap.NewProductionEncoderConfig())
+	for i := 0; i < 10; i++ {
+		logger.Info("hello")
+	}
+	// the request is blocked, so the queue is full
+	assert.Greater(t, s.Stats().Dropped, int64(0))
+	close(release)
+	assert.NoError(t, s.Close())
+	stats := s.Stats()
+	assert.Equal(t, int64(10), stats.Sent+stats.Dropped)
+}
+
+func TestHTTPFailed(t *testing.T) {
+	m := metrics.New(metrics.WithNamespace("test"))
+	metrics.Enable(m)
+	defer metrics.Enable(nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	s, err := NewHTTP(HTTPConfig{URL: server.URL, Format: FormatLoki})
+	assert.NoError(t, err)
+	logger := newTestLogger(s, zap.NewProductionEncoderConfig())
+	logger.Info("hello")
+	logger.Info("world")
+	assert.NoError(t, s.Close())
+
+	assert.Equal(t, HTTPStats{Failed: 2}, s.Stats())
+	assert.Contains(t, metricsText(m), `test_log_failed_total{sink="loki"} 2`)
+}
+
+func TestGELF(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	s, err := NewGELF(GELFConfig{Network: "udp", Address: conn.LocalAddr().String(), Host: "host"})
+	assert.NoError(t, err)
+	defer s.Close()
+	logger := newTestLogger(s, GELFEncoderConfig())
+	logger.Error("hello", zap.String("user", "tom"), zap.String("id", "1"), zap.Int("count", 2))
+
+	buf := make([]byte, gelfChunkSize)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	var msg map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf[:n], &msg))
+	assert.Equal(t, "1.1", msg["version"])
+	assert.Equal(t, "host", msg["host"])
+	assert.Equal(t, "hello", msg["short_message"])
+	assert.Equal(t, float64(3), msg["level"])
+	assert.Equal(t, "tom", msg["_user"])
+	assert.Equal(t, "1", msg["_id_"])
+	assert.Equal(t, float64(2), msg["_count"])
+
+	// the large message is chunked
+	logger.Info(strings.Repeat("a", gelfChunkSize*2))
+	var chunks int
+	for {
+		n, _, err = conn.ReadFrom(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0x1e, 0x0f}, buf[:2])
+		chunks++
+		if int(buf[10]) == int(buf[11])-1 {
+			break
+		}
+	}
+	assert.Equal(t, 3, chunks)
+}
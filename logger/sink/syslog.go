@@ -0,0 +1,190 @@
+package sink
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/liuxiong332/kratos-starter/metrics"
+)
+
+// SyslogConfig is the config of the RFC5424 syslog sink.
+type SyslogConfig struct {
+	// Network is udp, tcp or unix
+	Network string `json:"network"`
+	// Address is the host:port, or the socket path of the unix network
+	Address string `json:"address"`
+	// Facility is the syslog facility, default is 1 (user)
+	Facility int    `json:"facility"`
+	AppName  string `json:"app_name"`
+	// Hostname defaults to the os hostname
+	Hostname string `json:"hostname"`
+	// Timeout is the dial and write timeout, default is 5s
+	Timeout string `json:"timeout"`
+}
+
+// Syslog writes the entries as the RFC5424 messages, the messages are framed
+// by the octet counting of RFC6587 over the stream connections. The
+// connection is redialed after the write error, the entries are dropped and
+// counted by the log dropped metric before the next redial if it failed.
+type Syslog struct {
+	config   SyslogConfig
+	timeout  time.Duration
+	hostname string
+	pid      string
+
+	lock    sync.Mutex
+	conn    net.Conn
+	stream  bool
+	backoff redialBackoff
+}
+
+// NewSyslog creates the syslog sink, the connection is dialed by the first
+// write.
+func NewSyslog(c SyslogConfig) (*Syslog, error) {
+	switch c.Network {
+	case "udp", "tcp", "unix":
+	default:
+		return nil, fmt.Errorf("unknown syslog network %s", c.Network)
+	}
+	if c.Address == "" {
+		return nil, fmt.Errorf("syslog address is required")
+	}
+	timeout, err := parseDuration(c.Timeout, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("syslog timeout: %w", err)
+	}
+	if c.Facility == 0 {
+		c.Facility = 1
+	}
+	if c.Facility < 0 || c.Facility > 23 {
+		return nil, fmt.Errorf("invalid syslog facility %d", c.Facility)
+	}
+	hostname := c.Hostname
+	if hostname == "" {
+		if hostname, _ = os.Hostname(); hostname == "" {
+			hostname = "-"
+		}
+	}
+	return &Syslog{
+		config:   c,
+		timeout:  timeout,
+		hostname: hostname,
+		pid:      strconv.Itoa(os.Getpid()),
+		backoff:  newRedialBackoff(),
+	}, nil
+}
+
+// Format returns the RFC5424 message of the entry.
+func (s *Syslog) Format(ent zapcore.Entry, line []byte) []byte {
+	appName, msgID := s.config.AppName, ent.LoggerName
+	if appName == "" {
+		appName = "-"
+	}
+	if msgID == "" {
+		msgID = "-"
+	}
+	pri := s.config.Facility*8 + severity(ent.Level)
+	header := fmt.Sprintf("<%d>1 %s %s %s %s %s - ", pri, ent.Time.Format("2006-01-02T15:04:05.000000Z07:00"), s.hostname, appName, s.pid, msgID)
+	return append([]byte(header), trimNewline(line)...)
+}
+
+func (s *Syslog) WriteEntry(ent zapcore.Entry, line []byte) error {
+	msg := s.Format(ent, line)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	if s.conn == nil && !s.backoff.allow(now) {
+		// drop the entry until the next redial
+		metrics.Default().LogDropped("syslog")
+		return nil
+	}
+	err := s.write(msg)
+	if err != nil && s.conn != nil {
+		// redial once, the server may be restarted
+		s.closeConn()
+		err = s.write(msg)
+	}
+	if err != nil {
+		s.closeConn()
+		s.backoff.fail(now)
+		metrics.Default().LogDropped("syslog")
+		return err
+	}
+	s.backoff.reset()
+	return nil
+}
+
+func (s *Syslog) write(msg []byte) error {
+	if s.conn == nil {
+		if err := s.dial(); err != nil {
+			return err
+		}
+	}
+	if s.stream {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+func (s *Syslog) dial() error {
+	var err error
+	switch s.config.Network {
+	case "unix":
+		// the syslog daemon listens the datagram socket mostly
+		if s.conn, err = net.DialTimeout("unixgram", s.config.Address, s.timeout); err != nil {
+			s.conn, err = net.DialTimeout("unix", s.config.Address, s.timeout)
+			s.stream = true
+		} else {
+			s.stream = false
+		}
+	default:
+		s.conn, err = net.DialTimeout(s.config.Network, s.config.Address, s.timeout)
+		s.stream = s.config.Network == "tcp"
+	}
+	if err != nil {
+		s.conn = nil
+		return fmt.Errorf("dial syslog %s: %w", s.config.Address, err)
+	}
+	return nil
+}
+
+func (s *Syslog) closeConn() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *Syslog) Sync() error {
+	return nil
+}
+
+func (s *Syslog) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closeConn()
+	return nil
+}
+
+func parseDuration(s string, defaultValue time.Duration) (time.Duration, error) {
+	if s == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,109 @@
+package zap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// BadKey is the key of the value without the key in the odd keyvals.
+const BadKey = "!BADKEY"
+
+// fields converts the keyvals into the message and the zap fields, the
+// valuers are resolved by the ctx and omitted if resolved to nil.
+func fields(ctx context.Context, keyvals []interface{}) (string, []zap.Field) {
+	var msg string
+	fs := make([]zap.Field, 0, len(keyvals)/2+1)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i == len(keyvals)-1 {
+			fs = append(fs, field(BadKey, value(ctx, keyvals[i])))
+			break
+		}
+		key, v := keyString(keyvals[i]), value(ctx, keyvals[i+1])
+		if _, ok := keyvals[i+1].(log.Valuer); ok && v == nil {
+			continue
+		}
+		if key == log.DefaultMessageKey {
+			if s, ok := v.(string); ok {
+				msg = s
+			} else {
+				msg = fmt.Sprint(v)
+			}
+			continue
+		}
+		fs = append(fs, field(key, v))
+	}
+	return msg, fs
+}
+
+func keyString(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+func value(ctx context.Context, v interface{}) interface{} {
+	if valuer, ok := v.(log.Valuer); ok {
+		return valuer(ctx)
+	}
+	return v
+}
+
+// field returns the typed field of the common types, to avoid the reflection
+// of zap.Any. The marshalers are checked before error and fmt.Stringer like
+// zap.Any, so the types implementing both are encoded as the objects.
+func field(key string, v interface{}) zap.Field {
+	switch v := v.(type) {
+	case string:
+		return zap.String(key, v)
+	case int:
+		return zap.Int(key, v)
+	case int64:
+		return zap.Int64(key, v)
+	case int32:
+		return zap.Int32(key, v)
+	case int16:
+		return zap.Int16(key, v)
+	case int8:
+		return zap.Int8(key, v)
+	case uint:
+		return zap.Uint(key, v)
+	case uint64:
+		return zap.Uint64(key, v)
+	case uint32:
+		return zap.Uint32(key, v)
+	case uint16:
+		return zap.Uint16(key, v)
+	case uint8:
+		return zap.Uint8(key, v)
+	case float64:
+		return zap.Float64(key, v)
+	case float32:
+		return zap.Float32(key, v)
+	case bool:
+		return zap.Bool(key, v)
+	case time.Duration:
+		return zap.Duration(key, v)
+	case time.Time:
+		return zap.Time(key, v)
+	case []byte:
+		return zap.ByteString(key, v)
+	case zapcore.ObjectMarshaler:
+		return zap.Object(key, v)
+	case zapcore.ArrayMarshaler:
+		return zap.Array(key, v)
+	case error:
+		return zap.NamedError(key, v)
+	case fmt.Stringer:
+		return zap.Stringer(key, v)
+	case nil:
+		return zap.Reflect(key, nil)
+	default:
+		return zap.Any(key, v)
+	}
+}
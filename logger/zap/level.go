@@ -0,0 +1,208 @@
+package zap
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ModuleKey is the log field of the module name, like
+// log.With(logger, "module", "consul"). The name of the zap logger is the
+// module name too.
+const ModuleKey = "module"
+
+// Levels is the runtime adjustable level of the root and the modules. The
+// level of the module is the override, the config level of the module, then
+// the root level.
+type Levels struct {
+	lock      sync.RWMutex
+	levels    map[string]zapcore.Level
+	overrides map[string]*override
+	// min is the lowest level of all the modules, the entries below it are
+	// dropped before encoding the fields
+	min zap.AtomicLevel
+}
+
+type override struct {
+	level zapcore.Level
+	timer *time.Timer
+}
+
+// NewLevels creates the levels of the root level.
+func NewLevels(level zapcore.Level) *Levels {
+	return &Levels{
+		levels:    map[string]zapcore.Level{"": level},
+		overrides: make(map[string]*override),
+		min:       zap.NewAtomicLevelAt(level),
+	}
+}
+
+// SetLevels replaces the config levels of the root and the modules, the
+// overrides are kept.
+func (l *Levels) SetLevels(root zapcore.Level, modules map[string]zapcore.Level) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.levels = map[string]zapcore.Level{"": root}
+	for module, level := range modules {
+		if module != "" {
+			l.levels[module] = level
+		}
+	}
+	l.updateMin()
+}
+
+// Override sets the level of the module, the empty module is the root. The
+// override is reverted after the ttl, it is kept if the ttl is 0.
+func (l *Levels) Override(module string, level zapcore.Level, ttl time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if o, ok := l.overrides[module]; ok && o.timer != nil {
+		o.timer.Stop()
+	}
+	o := &override{level: level}
+	if ttl > 0 {
+		o.timer = time.AfterFunc(ttl, func() { l.revert(module, o) })
+	}
+	l.overrides[module] = o
+	l.updateMin()
+}
+
+// Revert removes the override of the module.
+func (l *Levels) Revert(module string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if o, ok := l.overrides[module]; ok {
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+		delete(l.overrides, module)
+		l.updateMin()
+	}
+}
+
+func (l *Levels) revert(module string, o *override) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	// the override may be replaced before the timer fires
+	if l.overrides[module] == o {
+		delete(l.overrides, module)
+		l.updateMin()
+	}
+}
+
+// Level returns the level of the module, the empty module is the root.
+func (l *Levels) Level(module string) zapcore.Level {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.level(module)
+}
+
+func (l *Levels) level(module string) zapcore.Level {
+	if module != "" {
+		if o, ok := l.overrides[module]; ok {
+			return o.level
+		}
+		if level, ok := l.levels[module]; ok {
+			return level
+		}
+	}
+	if o, ok := l.overrides[""]; ok {
+		return o.level
+	}
+	return l.levels[""]
+}
+
+// Modules returns the levels of the root and the modules configured or
+// overridden, the root is the empty module.
+func (l *Levels) Modules() map[string]zapcore.Level {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	modules := make(map[string]zapcore.Level, len(l.levels)+len(l.overrides))
+	for module := range l.levels {
+		modules[module] = l.level(module)
+	}
+	for module := range l.overrides {
+		modules[module] = l.level(module)
+	}
+	return modules
+}
+
+func (l *Levels) updateMin() {
+	min := l.level("")
+	for module := range l.levels {
+		if level := l.level(module); level < min {
+			min = level
+		}
+	}
+	for module := range l.overrides {
+		if level := l.level(module); level < min {
+			min = level
+		}
+	}
+	l.min.SetLevel(min)
+}
+
+// Enabled reports whether the level is enabled by any module.
+func (l *Levels) Enabled(level zapcore.Level) bool {
+	return l.min.Enabled(level)
+}
+
+// NewLevelCore returns the core which filters the entries by the level of
+// their module.
+func NewLevelCore(core zapcore.Core, levels *Levels) zapcore.Core {
+	return &levelCore{Core: core, levels: levels}
+}
+
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+	module string
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.Enabled(level) && c.Core.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	module := c.module
+	if m, ok := moduleOf(fields); ok {
+		module = m
+	}
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels, module: module}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.Enabled(ent.Level) {
+		return ce
+	}
+	// the module field of the entry is known in Write
+	return ce.AddCore(ent, c)
+}
+
+func (c *levelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	module := c.module
+	if m, ok := moduleOf(fields); ok {
+		module = m
+	} else if module == "" {
+		module = ent.LoggerName
+	}
+	if ent.Level < c.levels.Level(module) {
+		return nil
+	}
+	// the wrapped core checks its own level and sampling
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+func moduleOf(fields []zapcore.Field) (string, bool) {
+	for _, f := range fields {
+		if f.Key == ModuleKey && f.Type == zapcore.StringType {
+			return f.String, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,47 @@
+package zap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLevels(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	levels := NewLevels(zapcore.InfoLevel)
+	levels.SetLevels(zapcore.InfoLevel, map[string]zapcore.Level{"consul": zapcore.DebugLevel})
+	logger := NewLogger(zap.New(NewLevelCore(core, levels)), WithLevels(levels))
+
+	_ = logger.Log(log.LevelDebug, "msg", "root debug")
+	_ = log.With(logger, ModuleKey, "consul").Log(log.LevelDebug, "msg", "consul debug")
+	_ = logger.Log(log.LevelDebug, ModuleKey, "vault", "msg", "vault debug")
+	assert.Equal(t, []string{"consul debug"}, messages(logs))
+
+	levels.Override("vault", zapcore.DebugLevel, time.Millisecond*50)
+	_ = logger.Log(log.LevelDebug, ModuleKey, "vault", "msg", "vault debug")
+	assert.Equal(t, zapcore.DebugLevel, levels.Modules()["vault"])
+
+	time.Sleep(time.Millisecond * 100)
+	_ = logger.Log(log.LevelDebug, ModuleKey, "vault", "msg", "vault debug reverted")
+	assert.Equal(t, zapcore.InfoLevel, levels.Level("vault"))
+	assert.Equal(t, []string{"consul debug", "vault debug"}, messages(logs))
+
+	levels.Override("", zapcore.WarnLevel, 0)
+	_ = logger.Log(log.LevelInfo, "msg", "root info")
+	zap.New(NewLevelCore(core, levels)).Named("consul").Debug("named debug")
+	assert.Equal(t, []string{"consul debug", "vault debug", "named debug"}, messages(logs))
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("vault"))
+}
+
+func messages(logs *observer.ObservedLogs) []string {
+	var msgs []string
+	for _, entry := range logs.All() {
+		msgs = append(msgs, entry.Message)
+	}
+	return msgs
+}
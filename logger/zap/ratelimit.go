@@ -0,0 +1,215 @@
+package zap
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxRateLimitKeys bounds the limited messages, the new messages beyond it
+// share the overflow bucket of the module and level until the idle keys are
+// removed.
+const maxRateLimitKeys = 10000
+
+// overflowMessage is the suppressed_message of the overflow bucket summaries.
+const overflowMessage = "*"
+
+// RateLimit is the token bucket of every message of the module.
+type RateLimit struct {
+	// Rate is the entries per second, 0 disables the limit
+	Rate float64 `json:"rate"`
+	// Burst is the max entries at once, default is the rate and at least 1
+	Burst int `json:"burst"`
+}
+
+// RateLimitCore limits the entries of the same module, level and message by
+// the rate limit of the module. The suppressed entries are summarized by the
+// "N messages suppressed" entry every interval.
+type RateLimitCore struct {
+	*rateLimiter
+	zapcore.Core
+	module string
+}
+
+type rateLimiter struct {
+	core   zapcore.Core
+	limits map[string]RateLimit
+
+	lock    sync.Mutex
+	buckets map[rateLimitKey]*bucket
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+type rateLimitKey struct {
+	module  string
+	level   zapcore.Level
+	message string
+	// overflow is the key of the messages beyond maxRateLimitKeys
+	overflow bool
+}
+
+type bucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int64
+}
+
+// NewRateLimitCore returns the core limited by the limits of the modules, the
+// limit of the empty module is the default. The core should be closed to stop
+// the summaries.
+func NewRateLimitCore(core zapcore.Core, limits map[string]RateLimit, interval time.Duration) *RateLimitCore {
+	l := &rateLimiter{
+		core:    core,
+		limits:  limits,
+		buckets: make(map[rateLimitKey]*bucket),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go l.run(interval)
+	return &RateLimitCore{rateLimiter: l, Core: core}
+}
+
+func (c *RateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	module := c.module
+	if m, ok := moduleOf(fields); ok {
+		module = m
+	}
+	return &RateLimitCore{rateLimiter: c.rateLimiter, Core: c.Core.With(fields), module: module}
+}
+
+func (c *RateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		// the module field of the entry is known in Write
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *RateLimitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	module := c.module
+	if m, ok := moduleOf(fields); ok {
+		module = m
+	} else if module == "" {
+		module = ent.LoggerName
+	}
+	if !c.allow(rateLimitKey{module: module, level: ent.Level, message: ent.Message}, ent.Time) {
+		return nil
+	}
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+func (l *rateLimiter) limit(module string) RateLimit {
+	if limit, ok := l.limits[module]; ok {
+		return limit
+	}
+	return l.limits[""]
+}
+
+func (l *rateLimiter) allow(key rateLimitKey, now time.Time) bool {
+	limit := l.limit(key.module)
+	// the fatal entries are never dropped
+	if limit.Rate <= 0 || key.level > zapcore.ErrorLevel {
+		return true
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = limit.Rate
+		if burst < 1 {
+			burst = 1
+		}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	b, ok := l.buckets[key]
+	if !ok && len(l.buckets) >= maxRateLimitKeys {
+		key = rateLimitKey{module: key.module, level: key.level, overflow: true}
+		b, ok = l.buckets[key]
+	}
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * limit.Rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		b.suppressed++
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (l *rateLimiter) run(interval time.Duration) {
+	defer close(l.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.summarize(time.Now(), interval)
+		case <-l.done:
+			l.summarize(time.Now(), 0)
+			return
+		}
+	}
+}
+
+// summarize writes the summaries of the suppressed entries, and removes the
+// keys idle for the interval.
+func (l *rateLimiter) summarize(now time.Time, interval time.Duration) {
+	type summary struct {
+		key        rateLimitKey
+		suppressed int64
+	}
+	var summaries []summary
+	l.lock.Lock()
+	for key, b := range l.buckets {
+		if b.suppressed > 0 {
+			summaries = append(summaries, summary{key: key, suppressed: b.suppressed})
+			b.suppressed = 0
+		} else if interval > 0 && now.Sub(b.last) > interval {
+			delete(l.buckets, key)
+		}
+	}
+	l.lock.Unlock()
+
+	for _, s := range summaries {
+		message := s.key.message
+		if s.key.overflow {
+			message = overflowMessage
+		}
+		ent := zapcore.Entry{
+			Level:   s.key.level,
+			Time:    now,
+			Message: fmt.Sprintf("%d messages suppressed", s.suppressed),
+		}
+		fields := []zapcore.Field{zap.String("suppressed_message", message), zap.Int64("suppressed", s.suppressed)}
+		if s.key.module != "" {
+			fields = append(fields, zap.String(ModuleKey, s.key.module))
+		}
+		if ce := l.core.Check(ent, nil); ce != nil {
+			ce.Write(fields...)
+		}
+	}
+}
+
+// Close writes the last summaries and stops the summaries.
+func (c *RateLimitCore) Close() error {
+	c.once.Do(func() { close(c.done) })
+	<-c.stopped
+	return nil
+}
@@ -0,0 +1,68 @@
+package zap
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRateLimit(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	rateLimitCore := NewRateLimitCore(core, map[string]RateLimit{
+		"":         {Rate: 100, Burst: 100},
+		"registry": {Rate: 1, Burst: 2},
+	}, time.Hour)
+	logger := NewLogger(zap.New(rateLimitCore), WithCloser(rateLimitCore))
+
+	registryLogger := log.With(logger, ModuleKey, "registry")
+	for i := 0; i < 10; i++ {
+		_ = registryLogger.Log(log.LevelError, "msg", "resolve failed")
+		_ = logger.Log(log.LevelError, "msg", "resolve failed")
+	}
+	_ = registryLogger.Log(log.LevelError, "msg", "other")
+	assert.Equal(t, 2, logs.FilterMessage("resolve failed").FilterField(zap.String(ModuleKey, "registry")).Len())
+	assert.Equal(t, 12, logs.FilterMessage("resolve failed").Len())
+	assert.Equal(t, 1, logs.FilterMessage("other").Len())
+
+	// the suppressed entries are summarized when closed
+	assert.NoError(t, logger.Close())
+	summaries := logs.FilterMessage("8 messages suppressed").AllUntimed()
+	if assert.Len(t, summaries, 1) {
+		assert.Equal(t, zapcore.ErrorLevel, summaries[0].Level)
+		assert.Equal(t, map[string]interface{}{
+			"suppressed_message": "resolve failed",
+			"suppressed":         int64(8),
+			ModuleKey:            "registry",
+		}, summaries[0].ContextMap())
+	}
+}
+
+func TestRateLimitOverflow(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	rateLimitCore := NewRateLimitCore(core, map[string]RateLimit{"": {Rate: 1, Burst: 2}}, time.Hour)
+	now := time.Now()
+	rateLimitCore.lock.Lock()
+	for i := 0; i < maxRateLimitKeys; i++ {
+		rateLimitCore.buckets[rateLimitKey{level: zapcore.ErrorLevel, message: fmt.Sprint("msg", i)}] = &bucket{last: now}
+	}
+	rateLimitCore.lock.Unlock()
+
+	// the new messages beyond the max keys share the overflow bucket
+	logger := zap.New(rateLimitCore)
+	for i := 0; i < 10; i++ {
+		logger.Error(fmt.Sprint("new ", i))
+	}
+	assert.Equal(t, 2, logs.Len())
+
+	assert.NoError(t, rateLimitCore.Close())
+	summaries := logs.FilterMessage("8 messages suppressed").AllUntimed()
+	if assert.Len(t, summaries, 1) {
+		assert.Equal(t, overflowMessage, summaries[0].ContextMap()["suppressed_message"])
+	}
+}
@@ -1,53 +1,230 @@
 package zap
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"io"
+	"syscall"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var _ log.Logger = (*Logger)(nil)
 
+// Option is zap logger option.
+type Option func(l *Logger)
+
+// WithCloser closes the closer when the logger is closed, e.g. the log file.
+func WithCloser(closer io.Closer) Option {
+	return func(l *Logger) {
+		l.closers = append(l.closers, closer)
+	}
+}
+
+// WithLevels with the runtime adjustable levels of the logger core, see
+// NewLevelCore.
+func WithLevels(levels *Levels) Option {
+	return func(l *Logger) {
+		l.levels = levels
+	}
+}
+
+// WithFatalAction with the action after the fatal log, default is
+// zapcore.WriteThenFatal which exits the process. zapcore.WriteThenNoop only
+// writes the log, the caller is not annotated then.
+func WithFatalAction(action zapcore.CheckWriteAction) Option {
+	return func(l *Logger) {
+		l.fatalAction = action
+	}
+}
+
+// WithCallerSkip with the caller skip of the zap logger added for the kratos
+// log calls, Zap and Sugar remove it for the direct calls.
+func WithCallerSkip(skip int) Option {
+	return func(l *Logger) {
+		l.callerSkip = skip
+	}
+}
+
 type Logger struct {
-	log *zap.Logger
+	log         *zap.Logger
+	levels      *Levels
+	closers     []io.Closer
+	fatalAction zapcore.CheckWriteAction
+	callerSkip  int
+	// valuers are the valuer keyvals of With, resolved by ctx for every log
+	valuers []interface{}
+	ctx     context.Context
 }
 
-func NewLogger(zlog *zap.Logger) *Logger {
-	return &Logger{zlog}
+func NewLogger(zlog *zap.Logger, opts ...Option) *Logger {
+	l := &Logger{log: zlog, fatalAction: zapcore.WriteThenFatal, ctx: context.Background()}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.fatalAction != zapcore.WriteThenFatal && l.fatalAction != zapcore.WriteThenNoop {
+		l.log = l.log.WithOptions(zap.OnFatal(l.fatalAction))
+	}
+	return l
 }
 
+// Log converts the keyvals into the typed zap fields. The msg is the message,
+// the valuers are resolved, and the last value of the odd keyvals is logged
+// with the BadKey.
 func (l *Logger) Log(level log.Level, keyvals ...interface{}) error {
-	if len(keyvals) == 0 || len(keyvals)%2 != 0 {
-		l.log.Warn(fmt.Sprint("Keyvalues must appear in pairs: ", keyvals))
+	if level != log.LevelFatal && !l.log.Core().Enabled(zapLevel(level)) {
 		return nil
 	}
+	if len(l.valuers) > 0 {
+		keyvals = append(l.valuers[:len(l.valuers):len(l.valuers)], keyvals...)
+	}
+	msg, fs := fields(l.ctx, keyvals)
 
-	var data []zap.Field
-	var msg string = ""
-	for i := 0; i < len(keyvals); i += 2 {
-		if keyvals[i] == "msg" {
-			msg = keyvals[i+1].(string)
-		} else {
-			data = append(data, zap.Any(fmt.Sprint(keyvals[i]), keyvals[i+1]))
-		}
+	switch level {
+	case log.LevelDebug:
+		l.log.Debug(msg, fs...)
+	case log.LevelWarn:
+		l.log.Warn(msg, fs...)
+	case log.LevelError:
+		l.log.Error(msg, fs...)
+	case log.LevelFatal:
+		l.fatal(msg, fs)
+	default:
+		l.log.Info(msg, fs...)
 	}
+	return nil
+}
 
+func zapLevel(level log.Level) zapcore.Level {
 	switch level {
 	case log.LevelDebug:
-		l.log.Debug(msg, data...)
-	case log.LevelInfo:
-		l.log.Info(msg, data...)
+		return zapcore.DebugLevel
 	case log.LevelWarn:
-		l.log.Warn(msg, data...)
+		return zapcore.WarnLevel
 	case log.LevelError:
-		l.log.Error(msg, data...)
+		return zapcore.ErrorLevel
 	case log.LevelFatal:
-		l.log.Fatal(msg, data...)
+		return zapcore.FatalLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// With returns the child logger with the keyvals, the fields are encoded once
+// into the child zap core instead of every log like log.With. The valuers are
+// still resolved by every log with the ctx of WithContext, also for the logs
+// of Zap and Sugar. The child shares the levels and is not closed with the
+// parent.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	child := *l
+	child.closers = nil
+	var static []zap.Field
+	for i := 0; i < len(keyvals); i += 2 {
+		if i == len(keyvals)-1 {
+			static = append(static, field(BadKey, keyvals[i]))
+			break
+		}
+		if _, ok := keyvals[i+1].(log.Valuer); ok {
+			child.valuers = append(child.valuers[:len(child.valuers):len(child.valuers)], keyvals[i], keyvals[i+1])
+			continue
+		}
+		static = append(static, field(keyString(keyvals[i]), keyvals[i+1]))
+	}
+	child.log = l.log.With(static...)
+	return &child
+}
+
+// WithContext returns the logger resolving the valuers of With by the ctx,
+// like the trace id of the request. The logger is not closed with the parent.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	child := *l
+	child.closers = nil
+	child.ctx = ctx
+	return &child
+}
+
+// Zap returns the zap logger for the hot paths, the caller skip of the kratos
+// calls is removed.
+func (l *Logger) Zap() *zap.Logger {
+	zlog := l.log
+	if len(l.valuers) > 0 {
+		zlog = zlog.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &valuerCore{Core: core, ctx: l.ctx, valuers: l.valuers}
+		}))
+	}
+	if l.callerSkip == 0 {
+		return zlog
+	}
+	return zlog.WithOptions(zap.AddCallerSkip(-l.callerSkip))
+}
+
+// valuerCore adds the fields of the valuers resolved by every entry.
+type valuerCore struct {
+	zapcore.Core
+	ctx     context.Context
+	valuers []interface{}
+}
+
+func (c *valuerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &valuerCore{Core: c.Core.With(fields), ctx: c.ctx, valuers: c.valuers}
+}
+
+func (c *valuerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *valuerCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	_, valuerFields := fields(c.ctx, c.valuers)
+	if ce := c.Core.Check(ent, nil); ce != nil {
+		ce.Write(append(valuerFields, fs...)...)
 	}
 	return nil
 }
 
+// Sugar returns the sugared logger of Zap.
+func (l *Logger) Sugar() *zap.SugaredLogger {
+	return l.Zap().Sugar()
+}
+
+func (l *Logger) fatal(msg string, fs []zap.Field) {
+	if l.fatalAction != zapcore.WriteThenNoop {
+		// skip the frame of fatal, so the caller is the same as the other levels
+		l.log.WithOptions(zap.AddCallerSkip(1)).Fatal(msg, fs...)
+		return
+	}
+	// zap always exits after the fatal log, so the entry is written by the core
+	ent := zapcore.Entry{Time: time.Now(), Level: zapcore.FatalLevel, Message: msg}
+	if ce := l.log.Core().Check(ent, nil); ce != nil {
+		ce.Write(fs...)
+	}
+}
+
+// Levels returns the levels of the logger, nil if the level is fixed.
+func (l *Logger) Levels() *Levels {
+	return l.levels
+}
+
 func (l *Logger) Sync() error {
 	return l.log.Sync()
 }
+
+// Close flushes the buffered logs and closes the closers of the logger.
+// The error to sync the console is ignored.
+func (l *Logger) Close() error {
+	err := l.Sync()
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		err = nil
+	}
+	for _, closer := range l.closers {
+		if closeErr := closer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
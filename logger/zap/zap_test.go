@@ -0,0 +1,192 @@
+package zap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLog(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core))
+
+	valuer := log.Valuer(func(context.Context) interface{} { return "resolved" })
+	_ = logger.Log(log.LevelInfo, "msg", 42, "user", "tom", "count", 3, "cost", time.Second,
+		"err", errors.New("failed"), "value", valuer, "dangling")
+	_ = logger.Log(log.LevelWarn)
+
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "42", entries[0].Message)
+		assert.Equal(t, map[string]interface{}{
+			"user":  "tom",
+			"count": int64(3),
+			"cost":  time.Second,
+			"err":   "failed",
+			"value": "resolved",
+			BadKey:  "dangling",
+		}, entries[0].ContextMap())
+		assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	}
+}
+
+func TestLogFatalAction(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core), WithFatalAction(zapcore.WriteThenNoop))
+	_ = logger.Log(log.LevelFatal, "msg", "fatal")
+	assert.Equal(t, 1, logs.FilterMessage("fatal").Len())
+
+	logger = NewLogger(zap.New(core), WithFatalAction(zapcore.WriteThenPanic))
+	assert.Panics(t, func() { _ = logger.Log(log.LevelFatal, "msg", "panic") })
+}
+
+func TestLogFatalCaller(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewLogger(zap.New(core, zap.AddCaller()), WithFatalAction(zapcore.WriteThenPanic))
+	_ = logger.Log(log.LevelInfo, "msg", "info")
+	assert.Panics(t, func() { _ = logger.Log(log.LevelFatal, "msg", "fatal") })
+
+	// the fatal caller is the frame of Log like the other levels
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, entries[0].Caller.Function, entries[1].Caller.Function)
+	}
+}
+
+// user is the fmt.Stringer and zapcore.ObjectMarshaler.
+type user struct {
+	name string
+}
+
+func (u user) String() string {
+	return u.name
+}
+
+func (u user) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.name)
+	return nil
+}
+
+func TestLogObjectMarshaler(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	_ = NewLogger(zap.New(core)).Log(log.LevelInfo, "user", user{name: "tom"})
+
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, map[string]interface{}{"name": "tom"}, entries[0].ContextMap()["user"])
+	}
+}
+
+func TestWith(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewLogger(zap.New(core))
+
+	count := 0
+	child := logger.With("module", "consul", "seq", log.Valuer(func(context.Context) interface{} {
+		count++
+		return count
+	}))
+	_ = child.Log(log.LevelInfo, "msg", "first")
+	_ = child.Log(log.LevelInfo, "msg", "second")
+	_ = logger.Log(log.LevelInfo, "msg", "parent")
+
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, map[string]interface{}{"module": "consul", "seq": int64(1)}, entries[0].ContextMap())
+		assert.Equal(t, map[string]interface{}{"module": "consul", "seq": int64(2)}, entries[1].ContextMap())
+		assert.Empty(t, entries[2].ContextMap())
+	}
+}
+
+type ctxKey struct{}
+
+func TestWithValuerContext(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewLogger(zap.New(core))
+	child := logger.With("request_id", log.Valuer(func(ctx context.Context) interface{} {
+		id, _ := ctx.Value(ctxKey{}).(string)
+		return id
+	}))
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "req-1")
+	_ = child.WithContext(ctx).Log(log.LevelInfo, "msg", "kratos")
+	child.WithContext(ctx).Zap().Info("zap", zap.String("user", "tom"))
+	child.Sugar().Info("sugar")
+
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, map[string]interface{}{"request_id": "req-1"}, entries[0].ContextMap())
+		assert.Equal(t, map[string]interface{}{"request_id": "req-1", "user": "tom"}, entries[1].ContextMap())
+		assert.Equal(t, map[string]interface{}{"request_id": ""}, entries[2].ContextMap())
+	}
+}
+
+func TestWithValuerNil(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	child := NewLogger(zap.New(core)).With("trace_id", log.Valuer(func(ctx context.Context) interface{} {
+		return ctx.Value(ctxKey{})
+	}))
+
+	// the valuer resolved to nil is omitted
+	_ = child.Log(log.LevelInfo, "msg", "kratos")
+	child.Zap().Info("zap")
+
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 2) {
+		assert.Empty(t, entries[0].ContextMap())
+		assert.Empty(t, entries[1].ContextMap())
+	}
+}
+
+func TestZap(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewLogger(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)), WithCallerSkip(1))
+
+	logger.Zap().Info("zap")
+	logger.Sugar().Infow("sugar", "user", "tom")
+	entries := logs.AllUntimed()
+	if assert.Len(t, entries, 2) {
+		assert.Contains(t, entries[0].Caller.File, "zap_test.go")
+		assert.Equal(t, map[string]interface{}{"user": "tom"}, entries[1].ContextMap())
+	}
+}
+
+func newBenchmarkLogger() *Logger {
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	return NewLogger(zap.New(zapcore.NewCore(encoder, zapcore.AddSync(io.Discard), zapcore.DebugLevel)))
+}
+
+// BenchmarkHelper logs through the kratos helper and the keyval adapter.
+func BenchmarkHelper(b *testing.B) {
+	helper := log.NewHelper(log.With(newBenchmarkLogger(), "module", "bench"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		helper.Infow("msg", "hello", "user", "tom", "count", i)
+	}
+}
+
+// BenchmarkWith logs by the child logger with the pre-encoded fields.
+func BenchmarkWith(b *testing.B) {
+	logger := newBenchmarkLogger().With("module", "bench")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = logger.Log(log.LevelInfo, "msg", "hello", "user", "tom", "count", i)
+	}
+}
+
+// BenchmarkZap logs by the native zap logger.
+func BenchmarkZap(b *testing.B) {
+	logger := newBenchmarkLogger().With("module", "bench").Zap()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Info("hello", zap.String("user", "tom"), zap.Int("count", i))
+	}
+}
@@ -1,15 +1,21 @@
 package machinery
 
 import (
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/RichardKnop/machinery/v2"
 	"github.com/RichardKnop/machinery/v2/config"
 	"github.com/RichardKnop/machinery/v2/log"
 	"github.com/RichardKnop/machinery/v2/tasks"
+	"go.opentelemetry.io/otel/trace"
 
 	redisbackend "github.com/RichardKnop/machinery/v2/backends/redis"
 	redisbroker "github.com/RichardKnop/machinery/v2/brokers/redis"
 	redislock "github.com/RichardKnop/machinery/v2/locks/redis"
 
+	"github.com/liuxiong332/kratos-starter/metrics"
 	mongoUtils "github.com/liuxiong332/kratos-starter/mongo"
 	redisUtils "github.com/liuxiong332/kratos-starter/redis"
 )
@@ -71,10 +77,20 @@ func Worker(server *machinery.Server, consumerTag string) error {
 
 	preTaskHandler := func(signature *tasks.Signature) {
 		log.INFO.Println("I am a start of task handler for:", signature.Name)
+		runningTasks.Store(signature.UUID, &runningTask{start: time.Now(), span: startTaskSpan(signature)})
 	}
 
 	postTaskHandler := func(signature *tasks.Signature) {
 		log.INFO.Println("I am an end of task handler for:", signature.Name)
+		v, ok := runningTasks.LoadAndDelete(signature.UUID)
+		if !ok {
+			return
+		}
+		task := v.(*runningTask)
+		task.span.End()
+		if m := metrics.Default(); m != nil {
+			m.MachineryTask(signature.Name, taskOutcome(server, signature), time.Since(task.start))
+		}
 	}
 
 	worker.SetPostTaskHandler(postTaskHandler)
@@ -83,3 +99,21 @@ func Worker(server *machinery.Server, consumerTag string) error {
 
 	return worker.Launch()
 }
+
+// runningTasks are the running tasks of the workers by the task uuid.
+var runningTasks sync.Map
+
+type runningTask struct {
+	start time.Time
+	span  trace.Span
+}
+
+// taskOutcome returns the state of the processed task in the backend, like
+// success, failure or retry.
+func taskOutcome(server *machinery.Server, signature *tasks.Signature) string {
+	state, err := server.GetBackend().GetState(signature.UUID)
+	if err != nil || state == nil {
+		return "unknown"
+	}
+	return strings.ToLower(state.State)
+}
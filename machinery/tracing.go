@@ -0,0 +1,134 @@
+package machinery
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/RichardKnop/machinery/v2"
+	"github.com/RichardKnop/machinery/v2/backends/result"
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/liuxiong332/kratos-starter/machinery"
+
+// headersCarrier is the propagation carrier of the signature headers.
+type headersCarrier tasks.Headers
+
+func (c headersCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+func (c headersCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+func (c headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// InjectTraceContext writes the trace context of the ctx to the signature
+// headers.
+func InjectTraceContext(ctx context.Context, signature *tasks.Signature) {
+	if signature.Headers == nil {
+		signature.Headers = tasks.Headers{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headersCarrier(signature.Headers))
+}
+
+// TraceContext returns the ctx with the trace context of the signature headers,
+// the task can start its spans by it.
+func TraceContext(ctx context.Context, signature *tasks.Signature) context.Context {
+	if signature.Headers == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, headersCarrier(signature.Headers))
+}
+
+// TraceTask returns the task function which receives the ctx with the
+// consumer span of the task started by the Worker, so the spans started by the
+// task are the children of the consumer span. The taskFunc is returned as is if
+// its first argument is not context.Context.
+func TraceTask(taskFunc interface{}) interface{} {
+	fn := reflect.ValueOf(taskFunc)
+	t := fn.Type()
+	// the invalid task is rejected by the registration
+	if t.Kind() != reflect.Func || t.NumIn() == 0 || !tasks.IsContextType(t.In(0)) {
+		return taskFunc
+	}
+	call := fn.Call
+	if t.IsVariadic() {
+		call = fn.CallSlice
+	}
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		ctx, _ := args[0].Interface().(context.Context)
+		args[0] = reflect.ValueOf(taskContext(ctx))
+		return call(args)
+	}).Interface()
+}
+
+// RegisterTasks registers the tasks traced by TraceTask.
+func RegisterTasks(server *machinery.Server, namedTaskFuncs map[string]interface{}) error {
+	traced := make(map[string]interface{}, len(namedTaskFuncs))
+	for name, taskFunc := range namedTaskFuncs {
+		traced[name] = TraceTask(taskFunc)
+	}
+	return server.RegisterTasks(traced)
+}
+
+// taskContext returns the ctx with the consumer span of the running task, or
+// the trace context of the signature headers if the task is not run by the
+// Worker.
+func taskContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	signature := tasks.SignatureFromContext(ctx)
+	if signature == nil {
+		return ctx
+	}
+	if v, ok := runningTasks.Load(signature.UUID); ok {
+		return trace.ContextWithSpan(ctx, v.(*runningTask).span)
+	}
+	return TraceContext(ctx, signature)
+}
+
+// SendTask sends the task in the producer span, the trace context is
+// propagated by the signature headers.
+func SendTask(ctx context.Context, server *machinery.Server, signature *tasks.Signature) (*result.AsyncResult, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "send "+signature.Name,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("machinery.task", signature.Name)),
+	)
+	defer span.End()
+
+	InjectTraceContext(ctx, signature)
+	asyncResult, err := server.SendTaskWithContext(ctx, signature)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return asyncResult, err
+}
+
+// startTaskSpan starts the consumer span of the task from the signature
+// headers.
+func startTaskSpan(signature *tasks.Signature) trace.Span {
+	_, span := otel.Tracer(tracerName).Start(TraceContext(context.Background(), signature), signature.Name,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("machinery.task", signature.Name),
+			attribute.String("machinery.task_uuid", signature.UUID),
+		),
+	)
+	return span
+}
+
+var _ propagation.TextMapCarrier = headersCarrier{}
@@ -0,0 +1,38 @@
+package machinery
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceTask(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	_, span := provider.Tracer("test").Start(context.Background(), "add")
+	defer span.End()
+	signature := &tasks.Signature{UUID: "task-1", Name: "add", Args: []tasks.Arg{{Type: "int64", Value: int64(1)}}}
+	runningTasks.Store(signature.UUID, &runningTask{span: span})
+	defer runningTasks.Delete(signature.UUID)
+
+	var spanContext trace.SpanContext
+	add := func(ctx context.Context, n int64) (int64, error) {
+		spanContext = trace.SpanContextFromContext(ctx)
+		return n + 1, nil
+	}
+	// the task is called like the worker
+	task, err := tasks.NewWithSignature(TraceTask(add), signature)
+	assert.NoError(t, err)
+	results, err := task.Call()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, results[0].Value)
+	assert.Equal(t, span.SpanContext(), spanContext)
+
+	// the task without ctx is not wrapped
+	noContext := func(n int64) error { return nil }
+	assert.Equal(t, reflect.ValueOf(noContext).Pointer(), reflect.ValueOf(TraceTask(noContext)).Pointer())
+}
@@ -0,0 +1,268 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Path is the path of the metrics handler.
+const Path = "/metrics"
+
+// Results of the config reload and the registry watch
+const (
+	ResultOK    = "ok"
+	ResultError = "error"
+)
+
+// States of the leader election
+const (
+	StateLeader   = "leader"
+	StateFollower = "follower"
+	StateError    = "error"
+)
+
+// Option is the metrics option.
+type Option func(*options)
+
+type options struct {
+	namespace string
+	registry  *prometheus.Registry
+}
+
+// WithNamespace with the namespace of the metric names, default is
+// kratos_starter.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithRegistry with the prometheus registry, a new registry with the go and
+// process collectors is created by default.
+func WithRegistry(registry *prometheus.Registry) Option {
+	return func(o *options) {
+		o.registry = registry
+	}
+}
+
+// Metrics is the prometheus metrics of the starter components. The methods do
+// nothing on the nil *Metrics, so the components are instrumented only if the
+// metrics is enabled.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	configReloads         *prometheus.CounterVec
+	registryWatchLatency  *prometheus.HistogramVec
+	registryInstances     *prometheus.GaugeVec
+	httpClientRequests    *prometheus.HistogramVec
+	machineryTasks        *prometheus.CounterVec
+	machineryTaskDuration *prometheus.HistogramVec
+	leaderTransitions     *prometheus.CounterVec
+	leader                *prometheus.GaugeVec
+	logDropped            *prometheus.CounterVec
+	logFailed             *prometheus.CounterVec
+}
+
+// New creates the metrics and registers them to the registry.
+func New(opts ...Option) *Metrics {
+	o := options{namespace: "kratos_starter"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.registry == nil {
+		o.registry = prometheus.NewRegistry()
+		o.registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+
+	m := &Metrics{
+		registry: o.registry,
+		configReloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Subsystem: "config",
+			Name:      "reloads_total",
+			Help:      "The config reloads of the source by the result.",
+		}, []string{"source", "result"}),
+		registryWatchLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Subsystem: "registry",
+			Name:      "watch_duration_seconds",
+			Help:      "The latency of the service watch query by the result.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 120},
+		}, []string{"service", "result"}),
+		registryInstances: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: o.namespace,
+			Subsystem: "registry",
+			Name:      "instances",
+			Help:      "The resolved instances of the service.",
+		}, []string{"service"}),
+		httpClientRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "The duration of the http client requests by the operation and the status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "status"}),
+		machineryTasks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Subsystem: "machinery",
+			Name:      "tasks_total",
+			Help:      "The processed machinery tasks by the outcome.",
+		}, []string{"task", "outcome"}),
+		machineryTaskDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Subsystem: "machinery",
+			Name:      "task_duration_seconds",
+			Help:      "The duration of the machinery tasks.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"task"}),
+		leaderTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Subsystem: "leadership",
+			Name:      "transitions_total",
+			Help:      "The leader election transitions by the new state.",
+		}, []string{"path", "state"}),
+		leader: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: o.namespace,
+			Subsystem: "leadership",
+			Name:      "is_leader",
+			Help:      "Whether the node is the leader of the election path.",
+		}, []string{"path"}),
+		logDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Subsystem: "log",
+			Name:      "dropped_total",
+			Help:      "The log entries dropped by the sink.",
+		}, []string{"sink"}),
+		logFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Subsystem: "log",
+			Name:      "failed_total",
+			Help:      "The log entries of the failed requests of the sink.",
+		}, []string{"sink"}),
+	}
+	o.registry.MustRegister(
+		m.configReloads,
+		m.registryWatchLatency,
+		m.registryInstances,
+		m.httpClientRequests,
+		m.machineryTasks,
+		m.machineryTaskDuration,
+		m.leaderTransitions,
+		m.leader,
+		m.logDropped,
+		m.logFailed,
+	)
+	return m
+}
+
+var enabled atomic.Pointer[Metrics]
+
+// Enable sets the metrics used by the starter components, nil disables them.
+func Enable(m *Metrics) {
+	enabled.Store(m)
+}
+
+// Default returns the enabled metrics, nil if the metrics is not enabled.
+func Default() *Metrics {
+	return enabled.Load()
+}
+
+// Registry returns the prometheus registry of the metrics.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler returns the /metrics handler.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultOK
+}
+
+// ConfigReloaded records the config reload of the source.
+func (m *Metrics) ConfigReloaded(source string, err error) {
+	if m == nil {
+		return
+	}
+	m.configReloads.WithLabelValues(source, result(err)).Inc()
+}
+
+// RegistryWatched records the latency of the service watch query.
+func (m *Metrics) RegistryWatched(service string, latency time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.registryWatchLatency.WithLabelValues(service, result(err)).Observe(latency.Seconds())
+}
+
+// RegistryInstances records the resolved instance count of the service.
+func (m *Metrics) RegistryInstances(service string, count int) {
+	if m == nil {
+		return
+	}
+	m.registryInstances.WithLabelValues(service).Set(float64(count))
+}
+
+// HTTPClientRequest records the http client request, the status is the
+// response status code, or 0 if no response.
+func (m *Metrics) HTTPClientRequest(operation string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	statusLabel := ResultError
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	m.httpClientRequests.WithLabelValues(operation, statusLabel).Observe(duration.Seconds())
+}
+
+// MachineryTask records the processed machinery task, the outcome is the task
+// state like success, failure or retry.
+func (m *Metrics) MachineryTask(task string, outcome string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.machineryTasks.WithLabelValues(task, outcome).Inc()
+	m.machineryTaskDuration.WithLabelValues(task).Observe(duration.Seconds())
+}
+
+// LeadershipChanged records the leader election transition of the path, the
+// state is StateLeader, StateFollower or StateError.
+func (m *Metrics) LeadershipChanged(path string, state string) {
+	if m == nil {
+		return
+	}
+	m.leaderTransitions.WithLabelValues(path, state).Inc()
+	if state == StateLeader {
+		m.leader.WithLabelValues(path).Set(1)
+	} else {
+		m.leader.WithLabelValues(path).Set(0)
+	}
+}
+
+// LogDropped records the log entry dropped by the sink.
+func (m *Metrics) LogDropped(sink string) {
+	if m == nil {
+		return
+	}
+	m.logDropped.WithLabelValues(sink).Inc()
+}
+
+// LogFailed records the log entries of the failed request of the sink.
+func (m *Metrics) LogFailed(sink string, count int) {
+	if m == nil {
+		return
+	}
+	m.logFailed.WithLabelValues(sink).Add(float64(count))
+}
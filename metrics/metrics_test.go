@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New(WithNamespace("test"))
+	m.ConfigReloaded("consul", nil)
+	m.ConfigReloaded("consul", errors.New("timeout"))
+	m.RegistryWatched("vault", time.Second, nil)
+	m.RegistryInstances("vault", 3)
+	m.HTTPClientRequest("/api.Echo/Say", 200, time.Millisecond)
+	m.HTTPClientRequest("/api.Echo/Say", 0, time.Millisecond)
+	m.MachineryTask("add", "success", time.Millisecond)
+	m.LeadershipChanged("/leader", StateLeader)
+	m.LogFailed("loki", 3)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.configReloads.WithLabelValues("consul", ResultOK)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.configReloads.WithLabelValues("consul", ResultError)))
+	assert.Equal(t, float64(3), testutil.ToFloat64(m.registryInstances.WithLabelValues("vault")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.httpClientRequests))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.machineryTasks.WithLabelValues("add", "success")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.leader.WithLabelValues("/leader")))
+	assert.Equal(t, float64(3), testutil.ToFloat64(m.logFailed.WithLabelValues("loki")))
+
+	m.LeadershipChanged("/leader", StateFollower)
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.leader.WithLabelValues("/leader")))
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", Path, nil))
+	assert.True(t, strings.Contains(w.Body.String(), `test_http_client_request_duration_seconds_count{operation="/api.Echo/Say",status="200"} 1`))
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	assert.NotPanics(t, func() {
+		m.ConfigReloaded("consul", nil)
+		m.HTTPClientRequest("/", 200, time.Millisecond)
+		m.LeadershipChanged("/leader", StateLeader)
+	})
+}
@@ -17,6 +17,10 @@ type Client struct {
 	cli    *api.Client
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// healthCheckPath is the http path of the health check, the tcp check is
+	// used if empty
+	healthCheckPath string
 }
 
 // NewClient creates consul client
@@ -109,12 +113,17 @@ func (d *Client) Register(ctx context.Context, svc *registry.ServiceInstance, en
 		Checks:          []*api.AgentServiceCheck{},
 	}
 	if enableHealthCheck {
-		asr.Checks = append(asr.Checks, &api.AgentServiceCheck{
+		check := &api.AgentServiceCheck{
 			TCP:                            fmt.Sprintf("%s:%d", addr, port),
 			Interval:                       "5s",
 			Status:                         "passing",
 			DeregisterCriticalServiceAfter: "20s",
-		})
+		}
+		if d.healthCheckPath != "" && httpAddr != "" && httpPort != 0 {
+			check.TCP = ""
+			check.HTTP = fmt.Sprintf("http://%s:%d/%s", httpAddr, httpPort, strings.TrimPrefix(d.healthCheckPath, "/"))
+		}
+		asr.Checks = append(asr.Checks, check)
 	}
 	err := d.cli.Agent().ServiceRegister(asr)
 	if err != nil {
@@ -2,6 +2,7 @@ package consul
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/go-kratos/kratos/v2/registry"
 	"github.com/hashicorp/consul/api"
+
+	"github.com/liuxiong332/kratos-starter/metrics"
 )
 
 var (
@@ -26,6 +29,16 @@ func WithHealthCheck(enable bool) Option {
 	}
 }
 
+// WithHealthCheckPath with the http path of the health check like the liveness
+// path /healthz, the http endpoint of the service is checked instead of the tcp
+// address. Do not use the readiness path /readyz, it runs the consul checker
+// and the instance is deregistered when consul itself is unhealthy.
+func WithHealthCheckPath(path string) Option {
+	return func(o *Registry) {
+		o.cli.healthCheckPath = path
+	}
+}
+
 // WithTags with customized tags option.
 func WithTags(tags []string) Option {
 	return func(o *Registry) {
@@ -47,6 +60,10 @@ type Registry struct {
 
 	registry map[string]*serviceSet
 	lock     sync.RWMutex
+
+	// cancel the service resolution
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // New creates consul registry
@@ -56,6 +73,7 @@ func New(apiClient *api.Client, opts ...Option) *Registry {
 		registry:          make(map[string]*serviceSet),
 		enableHealthCheck: true,
 	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
 	for _, o := range opts {
 		o(r)
 	}
@@ -141,9 +159,25 @@ func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, er
 	return w, nil
 }
 
+// Close stops resolving the watched services.
+func (r *Registry) Close() error {
+	r.cancel()
+	return nil
+}
+
+// service queries the service and records the latency.
+func (r *Registry) service(ctx context.Context, name string, index uint64) ([]*registry.ServiceInstance, uint64, error) {
+	start := time.Now()
+	services, idx, err := r.cli.Service(ctx, name, index, true)
+	if !errors.Is(err, context.Canceled) {
+		metrics.Default().RegistryWatched(name, time.Since(start), err)
+	}
+	return services, idx, err
+}
+
 func (r *Registry) resolve(ss *serviceSet) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	services, idx, err := r.cli.Service(ctx, ss.serviceName, 0, true)
+	ctx, cancel := context.WithTimeout(r.ctx, time.Second*10)
+	services, idx, err := r.service(ctx, ss.serviceName, 0)
 	cancel()
 	if err == nil {
 		ss.broadcast(services)
@@ -151,12 +185,20 @@ func (r *Registry) resolve(ss *serviceSet) {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 	for {
-		<-ticker.C
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*120)
-		tmpService, tmpIdx, err := r.cli.Service(ctx, ss.serviceName, idx, true)
+		select {
+		case <-ticker.C:
+		case <-r.ctx.Done():
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.ctx, time.Second*120)
+		tmpService, tmpIdx, err := r.service(ctx, ss.serviceName, idx)
 		cancel()
 		if err != nil {
-			time.Sleep(time.Second)
+			select {
+			case <-time.After(time.Second):
+			case <-r.ctx.Done():
+				return
+			}
 			continue
 		}
 		if tmpIdx != idx {
@@ -5,6 +5,8 @@ import (
 	"sync/atomic"
 
 	"github.com/go-kratos/kratos/v2/registry"
+
+	"github.com/liuxiong332/kratos-starter/metrics"
 )
 
 type serviceSet struct {
@@ -16,6 +18,7 @@ type serviceSet struct {
 
 func (s *serviceSet) broadcast(ss []*registry.ServiceInstance) {
 	s.services.Store(ss)
+	metrics.Default().RegistryInstances(s.serviceName, len(ss))
 	s.lock.RLock()
 	defer s.lock.RUnlock()
 	for k := range s.watcher {
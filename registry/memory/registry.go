@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var (
+	_ registry.Registrar = &Registry{}
+	_ registry.Discovery = &Registry{}
+)
+
+// Registry is the in-process registry, the services are only visible in the
+// current process. It is used in local mode and tests.
+type Registry struct {
+	services map[string][]*registry.ServiceInstance
+	watchers map[string]map[*watcher]struct{}
+	lock     sync.RWMutex
+}
+
+// New creates in-process registry
+func New() *Registry {
+	return &Registry{
+		services: make(map[string][]*registry.ServiceInstance),
+		watchers: make(map[string]map[*watcher]struct{}),
+	}
+}
+
+// Register register service
+func (r *Registry) Register(ctx context.Context, svc *registry.ServiceInstance) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	services := make([]*registry.ServiceInstance, 0, len(r.services[svc.Name])+1)
+	for _, s := range r.services[svc.Name] {
+		if s.ID != svc.ID {
+			services = append(services, s)
+		}
+	}
+	r.services[svc.Name] = append(services, svc)
+	r.broadcast(svc.Name)
+	return nil
+}
+
+// Deregister deregister service
+func (r *Registry) Deregister(ctx context.Context, svc *registry.ServiceInstance) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	services := make([]*registry.ServiceInstance, 0, len(r.services[svc.Name]))
+	for _, s := range r.services[svc.Name] {
+		if s.ID != svc.ID {
+			services = append(services, s)
+		}
+	}
+	r.services[svc.Name] = services
+	r.broadcast(svc.Name)
+	return nil
+}
+
+// GetService return service by name
+func (r *Registry) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	services := r.services[name]
+	if len(services) == 0 {
+		return nil, fmt.Errorf("service %s not found in registry", name)
+	}
+	return append([]*registry.ServiceInstance{}, services...), nil
+}
+
+// Watch resolve service by name, the first Next returns the current services
+// immediately even if there is no service.
+func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	w := &watcher{
+		registry: r,
+		name:     name,
+		event:    make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	if r.watchers[name] == nil {
+		r.watchers[name] = make(map[*watcher]struct{})
+	}
+	r.watchers[name][w] = struct{}{}
+	w.event <- struct{}{}
+	return w, nil
+}
+
+func (r *Registry) broadcast(name string) {
+	for w := range r.watchers[name] {
+		select {
+		case w.event <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type watcher struct {
+	registry *Registry
+	name     string
+	event    chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.event:
+	}
+	w.registry.lock.RLock()
+	defer w.registry.lock.RUnlock()
+	return append([]*registry.ServiceInstance{}, w.registry.services[w.name]...), nil
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	w.registry.lock.Lock()
+	defer w.registry.lock.Unlock()
+	delete(w.registry.watchers[w.name], w)
+	return nil
+}
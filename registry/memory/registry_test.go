@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := New()
+	ctx := context.Background()
+
+	w, err := r.Watch(ctx, "test-provider")
+	assert.NoError(t, err)
+	defer w.Stop()
+
+	services, err := w.Next()
+	assert.NoError(t, err)
+	assert.Empty(t, services)
+
+	svc := &registry.ServiceInstance{
+		ID:        "test2233",
+		Name:      "test-provider",
+		Version:   "v1",
+		Endpoints: []string{"http://127.0.0.1:8000"},
+	}
+	assert.NoError(t, r.Register(ctx, svc))
+
+	services, err = w.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(services))
+	assert.EqualValues(t, "test2233", services[0].ID)
+
+	services, err = r.GetService(ctx, "test-provider")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(services))
+
+	assert.NoError(t, r.Deregister(ctx, svc))
+	services, err = w.Next()
+	assert.NoError(t, err)
+	assert.Empty(t, services)
+
+	_, err = r.GetService(ctx, "test-provider")
+	assert.Error(t, err)
+}
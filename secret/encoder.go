@@ -0,0 +1,68 @@
+package secret
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// encoder masks the fields of the secret keys and redacts the secret values
+// from the message and the string fields.
+type encoder struct {
+	zapcore.Encoder
+	masker *Masker
+}
+
+// NewZapEncoder wraps the zap encoder to redact the secrets by the masker.
+func NewZapEncoder(enc zapcore.Encoder, masker *Masker) zapcore.Encoder {
+	return &encoder{Encoder: enc, masker: masker}
+}
+
+func (e *encoder) Clone() zapcore.Encoder {
+	return &encoder{Encoder: e.Encoder.Clone(), masker: e.masker}
+}
+
+func (e *encoder) AddString(key, value string) {
+	if e.masker.IsSecretKey(key) {
+		value = Mask
+	}
+	e.Encoder.AddString(key, e.masker.Redact(value))
+}
+
+func (e *encoder) AddByteString(key string, value []byte) {
+	e.AddString(key, string(value))
+}
+
+func (e *encoder) AddReflected(key string, value interface{}) error {
+	if e.masker.IsSecretKey(key) {
+		e.Encoder.AddString(key, Mask)
+		return nil
+	}
+	return e.Encoder.AddReflected(key, value)
+}
+
+func (e *encoder) redactField(f zapcore.Field) zapcore.Field {
+	if e.masker.IsSecretKey(f.Key) {
+		return zap.String(f.Key, Mask)
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = e.masker.Redact(f.String)
+	case zapcore.ByteStringType:
+		return zap.String(f.Key, e.masker.Redact(string(f.Interface.([]byte))))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return zap.String(f.Key, e.masker.Redact(err.Error()))
+		}
+	}
+	return f
+}
+
+func (e *encoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	entry.Message = e.masker.Redact(entry.Message)
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = e.redactField(f)
+	}
+	return e.Encoder.EncodeEntry(entry, redacted)
+}
@@ -0,0 +1,164 @@
+package secret
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask is the replacement of the secret values.
+const Mask = "******"
+
+// minValueLen is the min length of the secret value to redact, the shorter
+// values like "1" or "true" are too common to redact in the text.
+const minValueLen = 4
+
+// DefaultPatterns is the default patterns of the secret keys.
+var DefaultPatterns = []string{"*password*", "*secret*", "*token*", "*credential*", "*private_key*", "*api_key*", "*apikey*"}
+
+var defaultMasker = NewMasker(DefaultPatterns...)
+
+// Default returns the default masker of the packages without their own
+// masker, the app starter clones it by Clone.
+func Default() *Masker {
+	return defaultMasker
+}
+
+// Masker tells the secret config keys by the marked keys and the glob
+// patterns, and redacts the marked secret values from the text.
+type Masker struct {
+	patterns []*regexp.Regexp
+	keys     map[string]struct{}
+	values   map[string]struct{}
+	replacer *strings.Replacer
+	lock     sync.RWMutex
+}
+
+// NewMasker creates the masker with the key patterns, the pattern matches the
+// key case-insensitively and * matches any characters.
+func NewMasker(patterns ...string) *Masker {
+	m := &Masker{
+		keys:   make(map[string]struct{}),
+		values: make(map[string]struct{}),
+	}
+	m.AddPatterns(patterns...)
+	return m
+}
+
+// Clone returns the copy of the masker, the patterns, keys and values added
+// to the copy do not change the masker.
+func (m *Masker) Clone() *Masker {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	c := &Masker{
+		patterns: append([]*regexp.Regexp(nil), m.patterns...),
+		keys:     make(map[string]struct{}, len(m.keys)),
+		values:   make(map[string]struct{}, len(m.values)),
+		replacer: m.replacer,
+	}
+	for key := range m.keys {
+		c.keys[key] = struct{}{}
+	}
+	for value := range m.values {
+		c.values[value] = struct{}{}
+	}
+	return c
+}
+
+func compilePattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(strings.ToLower(pattern), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// AddPatterns adds the secret key patterns like *password*.
+func (m *Masker) AddPatterns(patterns ...string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, pattern := range patterns {
+		m.patterns = append(m.patterns, compilePattern(pattern))
+	}
+}
+
+// MarkKey marks the key as secret.
+func (m *Masker) MarkKey(key string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.keys[key] = struct{}{}
+}
+
+// MarkValue marks the value as secret, it is redacted by Redact.
+func (m *Masker) MarkValue(value string) {
+	if len(value) < minValueLen {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.values[value]; ok {
+		return
+	}
+	m.values[value] = struct{}{}
+
+	oldnew := make([]string, 0, len(m.values)*2)
+	for v := range m.values {
+		oldnew = append(oldnew, v, Mask)
+	}
+	m.replacer = strings.NewReplacer(oldnew...)
+}
+
+// IsSecretKey reports whether the key is marked or matches the patterns.
+func (m *Masker) IsSecretKey(key string) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if _, ok := m.keys[key]; ok {
+		return true
+	}
+	lower := strings.ToLower(key)
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(lower) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact replaces the marked secret values in the text with Mask.
+func (m *Masker) Redact(s string) string {
+	m.lock.RLock()
+	replacer := m.replacer
+	m.lock.RUnlock()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+type redactedError struct {
+	err    error
+	masker *Masker
+}
+
+func (e *redactedError) Error() string {
+	return e.masker.Redact(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// RedactError returns the error whose message is redacted by the default
+// masker, the original error is unwrapped by errors.Is and errors.As.
+func RedactError(err error) error {
+	return Default().RedactError(err)
+}
+
+// RedactError returns the error whose message is redacted by the masker, the
+// original error is unwrapped by errors.Is and errors.As.
+func (m *Masker) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err, masker: m}
+}
@@ -0,0 +1,66 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMasker(t *testing.T) {
+	m := NewMasker("*password*")
+	assert.True(t, m.IsSecretKey("db.Password"))
+	assert.False(t, m.IsSecretKey("db.user"))
+
+	m.MarkKey("db.dsn")
+	assert.True(t, m.IsSecretKey("db.dsn"))
+
+	m.MarkValue("s3cr3t-value")
+	m.MarkValue("1")
+	assert.Equal(t, "connect with ****** failed 1", m.Redact("connect with s3cr3t-value failed 1"))
+}
+
+func TestMaskerClone(t *testing.T) {
+	m := NewMasker("*password*")
+	m.MarkValue("s3cr3t-value")
+	c := m.Clone()
+	c.AddPatterns("*passwd*")
+	c.MarkValue("other-value")
+
+	assert.True(t, c.IsSecretKey("db.passwd"))
+	assert.False(t, m.IsSecretKey("db.passwd"))
+	assert.Equal(t, "****** ******", c.Redact("s3cr3t-value other-value"))
+	assert.Equal(t, "****** other-value", m.Redact("s3cr3t-value other-value"))
+}
+
+func TestRedactError(t *testing.T) {
+	Default().MarkValue("redact-error-value")
+	cause := errors.New("login with redact-error-value")
+	err := RedactError(cause)
+	assert.Equal(t, "login with ******", err.Error())
+	assert.True(t, errors.Is(err, cause))
+}
+
+func TestZapEncoder(t *testing.T) {
+	m := NewMasker("*password*")
+	m.MarkValue("zap-secret-value")
+
+	var buf bytes.Buffer
+	enc := NewZapEncoder(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), m)
+	logger := zap.New(zapcore.NewCore(enc, zapcore.AddSync(&buf), zap.InfoLevel)).With(zap.String("password", "with-field"))
+	logger.Info("login zap-secret-value",
+		zap.String("db_password", "plain"),
+		zap.String("dsn", "user:zap-secret-value@host"),
+		zap.Error(errors.New("bad zap-secret-value")),
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "zap-secret-value")
+	assert.NotContains(t, out, "with-field")
+	assert.NotContains(t, out, "plain")
+	assert.Contains(t, out, `"dsn":"user:******@host"`)
+	assert.Contains(t, out, `"msg":"login ******"`)
+}
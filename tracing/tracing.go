@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kratosTracing "github.com/go-kratos/kratos/v2/middleware/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Protocols of the OTLP exporter
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// Config is the config of the tracing block.
+type Config struct {
+	// Endpoint is the OTLP endpoint like localhost:4317, the spans are not
+	// exported if empty
+	Endpoint string `json:"endpoint"`
+	Protocol string `json:"protocol" default:"grpc" validate:"oneof=grpc http"`
+	Insecure bool   `json:"insecure"`
+	// SamplerRatio is the ratio of the sampled root spans, the child spans
+	// follow the parent
+	SamplerRatio float64 `json:"sampler_ratio" default:"1" validate:"min=0,max=1"`
+	// ServiceName defaults to the app name
+	ServiceName string `json:"service_name"`
+}
+
+// Option is the tracer provider option.
+type Option func(*options)
+
+type options struct {
+	exporters []sdktrace.SpanExporter
+}
+
+// WithExporter with the extra span exporter, like the in-memory exporter in
+// the tests. The spans are exported synchronously.
+func WithExporter(exporter sdktrace.SpanExporter) Option {
+	return func(o *options) {
+		o.exporters = append(o.exporters, exporter)
+	}
+}
+
+// NewTracerProvider creates the tracer provider by the config, and sets it as
+// the global tracer provider with the W3C trace context and baggage
+// propagator. The provider should be shut down to flush the spans.
+func NewTracerProvider(ctx context.Context, cfg *Config, opts ...Option) (*sdktrace.TracerProvider, error) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if cfg == nil {
+		cfg = &Config{SamplerRatio: 1}
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SamplerRatio))),
+	}
+	if cfg.Endpoint != "" {
+		exporter, err := newOTLPExporter(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	}
+	for _, exporter := range o.exporters {
+		providerOpts = append(providerOpts, sdktrace.WithSyncer(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider, nil
+}
+
+func newOTLPExporter(ctx context.Context, cfg *Config) (*otlptrace.Exporter, error) {
+	var client otlptrace.Client
+	switch cfg.Protocol {
+	case "", ProtocolGRPC:
+		clientOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			clientOpts = append(clientOpts, otlptracegrpc.WithInsecure())
+		}
+		client = otlptracegrpc.NewClient(clientOpts...)
+	case ProtocolHTTP:
+		clientOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		client = otlptracehttp.NewClient(clientOpts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing protocol %s", cfg.Protocol)
+	}
+	exporter, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("new otlp exporter: %w", err)
+	}
+	return exporter, nil
+}
+
+// NewInMemoryExporter returns the exporter which keeps the spans in memory for
+// the tests, see WithExporter.
+func NewInMemoryExporter() *tracetest.InMemoryExporter {
+	return tracetest.NewInMemoryExporter()
+}
+
+// WithTraceFields returns the logger which adds the trace_id and span_id
+// fields of the span in the log context, like
+// log.NewHelper(tracing.WithTraceFields(logger)).WithContext(ctx).
+func WithTraceFields(logger log.Logger) log.Logger {
+	return log.With(logger, "trace_id", kratosTracing.TraceID(), "span_id", kratosTracing.SpanID())
+}
+
+// TraceIDValuer returns the valuer of the trace id of the span in the log
+// context, nil if no span, so the zap logger omits the field.
+func TraceIDValuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+			return sc.TraceID().String()
+		}
+		return nil
+	}
+}
+
+// SpanIDValuer returns the valuer of the span id of the span in the log
+// context, nil if no span, so the zap logger omits the field.
+func SpanIDValuer() log.Valuer {
+	return func(ctx context.Context) interface{} {
+		if sc := trace.SpanContextFromContext(ctx); sc.HasSpanID() {
+			return sc.SpanID().String()
+		}
+		return nil
+	}
+}
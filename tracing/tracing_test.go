@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/liuxiong332/kratos-starter/httpd"
+	"github.com/liuxiong332/kratos-starter/machinery"
+)
+
+func TestHTTPClientPropagation(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	provider, err := NewTracerProvider(context.Background(), &Config{SamplerRatio: 1, ServiceName: "test"}, WithExporter(exporter))
+	assert.NoError(t, err)
+	defer provider.Shutdown(context.Background())
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "parent")
+	client, err := httpd.NewClient(ctx)
+	assert.NoError(t, err)
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/hello", nil)
+	_, err = client.Do(req, httpd.Operation("/api.Hello/Say"))
+	assert.NoError(t, err)
+	span.End()
+
+	traceID := span.SpanContext().TraceID().String()
+	assert.True(t, strings.Contains(traceparent, traceID))
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "/api.Hello/Say", spans[0].Name)
+		assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind)
+		assert.Equal(t, span.SpanContext().SpanID(), spans[0].Parent.SpanID())
+	}
+}
+
+func TestMachineryPropagation(t *testing.T) {
+	provider, err := NewTracerProvider(context.Background(), nil)
+	assert.NoError(t, err)
+	defer provider.Shutdown(context.Background())
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "parent")
+	defer span.End()
+
+	signature := &tasks.Signature{Name: "add"}
+	machinery.InjectTraceContext(ctx, signature)
+	taskCtx := machinery.TraceContext(context.Background(), signature)
+	assert.Equal(t, span.SpanContext().TraceID(), trace.SpanContextFromContext(taskCtx).TraceID())
+}
+
+func TestSamplerRatio(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	provider, err := NewTracerProvider(context.Background(), &Config{SamplerRatio: 0}, WithExporter(exporter))
+	assert.NoError(t, err)
+	defer provider.Shutdown(context.Background())
+
+	_, span := otel.Tracer("test").Start(context.Background(), "dropped")
+	span.End()
+	assert.Empty(t, exporter.GetSpans())
+}
@@ -6,8 +6,20 @@ import (
 	"github.com/Comcast/go-leaderelection"
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-zookeeper/zk"
+
+	"github.com/liuxiong332/kratos-starter/logger/audit"
+	"github.com/liuxiong332/kratos-starter/metrics"
 )
 
+// recordLeadership records the leadership change by the audit log.
+func recordLeadership(leaderRootPath string, eventType string, reason string) {
+	event := audit.Event{Type: eventType, Target: leaderRootPath}
+	if reason != "" {
+		event.Details = map[string]string{"reason": reason}
+	}
+	_ = audit.Default().Record(event)
+}
+
 func takeLeader(zkConn *zk.Conn, leaderRootPath string, logger *log.Helper, onTakeLeadership func(ctx context.Context) error) {
 	candidate, err := leaderelection.NewElection(zkConn, leaderRootPath, "electron")
 
@@ -25,16 +37,20 @@ func takeLeader(zkConn *zk.Conn, leaderRootPath string, logger *log.Helper, onTa
 		case status, ok := <-candidate.Status():
 			if !ok {
 				logger.Info("Channel closed, election is terminated! Will retry leader election.")
+				metrics.Default().LeadershipChanged(leaderRootPath, metrics.StateError)
 				candidate.Resign()
 				if cancelFunc != nil {
+					recordLeadership(leaderRootPath, audit.EventLeadershipLost, "election terminated")
 					cancelFunc()
 				}
 				return
 			}
 			if status.Err != nil {
 				logger.Infof("Received election status error: %v! Will retry leader election", status.Err)
+				metrics.Default().LeadershipChanged(leaderRootPath, metrics.StateError)
 				candidate.Resign()
 				if cancelFunc != nil {
+					recordLeadership(leaderRootPath, audit.EventLeadershipLost, status.Err.Error())
 					cancelFunc()
 				}
 				return
@@ -44,12 +60,16 @@ func takeLeader(zkConn *zk.Conn, leaderRootPath string, logger *log.Helper, onTa
 			if status.Role == leaderelection.Leader {
 				// doLeaderStuff(candidate, status, respCh, connFailCh, waitFor)
 				logger.Info("Now this node is the leader")
+				metrics.Default().LeadershipChanged(leaderRootPath, metrics.StateLeader)
+				recordLeadership(leaderRootPath, audit.EventLeadershipAcquired, "")
 
 				ctx, cancelFunc = context.WithCancel(context.Background())
 
 				go onTakeLeadership(ctx)
 			} else if cancelFunc != nil {
 				logger.Info("Cancel leader runner")
+				metrics.Default().LeadershipChanged(leaderRootPath, metrics.StateFollower)
+				recordLeadership(leaderRootPath, audit.EventLeadershipLost, "follower")
 				cancelFunc()
 				cancelFunc = nil
 			}